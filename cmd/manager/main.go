@@ -18,23 +18,114 @@ package main
 import (
 	"flag"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/apis"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/awssession"
 	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/controller"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/controller/ingress"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/logging"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/namespacedcache"
 	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/webhook"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
-	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
-	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 )
 
 func main() {
 	var metricsAddr string
+	var enableWebhooks bool
+	var webhookPort int
+	var webhookCertDir string
+	var leaderElect bool
+	var leaderElectionNamespace string
+	var leaderElectionID string
+	var leaseDuration time.Duration
+	var renewDeadline time.Duration
+	var retryPeriod time.Duration
+	var gracefulShutdownTimeout time.Duration
+	var watchNamespaces string
+	var ingressSelector string
+	var defaultsFile string
+	var awsEndpoints string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the validating admission webhook, needs a serving certificate in --webhook-cert-dir.")
+	flag.IntVar(&webhookPort, "webhook-port", 9876, "The port the webhook server binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/cert", "The directory holding tls.crt and tls.key of the webhook server.")
+	flag.BoolVar(&leaderElect, "leader-elect", false, "Only reconcile while holding the leader election lease, so that several replicas can run side by side.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "", "The namespace of the leader election Lease, defaults to the namespace the controller runs in.")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "amazon-apigateway-ingress-controller-leader", "The name of the leader election Lease, controllers sharing it elect a single leader.")
+	flag.DurationVar(&leaseDuration, "leader-election-lease-duration", 15*time.Second, "How long replicas wait before taking over the lease of a leader that stopped renewing it.")
+	flag.DurationVar(&renewDeadline, "leader-election-renew-deadline", 10*time.Second, "How long the leader keeps retrying to renew the lease before it gives up leading and exits.")
+	flag.DurationVar(&retryPeriod, "leader-election-retry-period", 2*time.Second, "How often replicas try to acquire or renew the lease.")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 30*time.Second, "How long running reconciles get to finish on shutdown before the lease is released.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "", "Comma separated namespaces whose ingresses the controller serves, all namespaces if empty.")
+	flag.StringVar(&ingressSelector, "ingress-selector", "", "Label selector of the ingresses the controller serves, e.g. team=payments, all ingresses if empty.")
+	flag.StringVar(&defaultsFile, "defaults-file", "", "YAML file of annotation defaults for all ingresses, overridden by IngressClass parameters and the ingress annotations.")
+	flag.StringVar(&ingress.DefaultsConfigMapName, "defaults-configmap", ingress.DefaultsConfigMapName, "ConfigMap in --ingress-class-parameters-namespace of annotation defaults for all ingresses, overriding --defaults-file and reloaded on change.")
+	flag.StringVar(&ingress.ControllerName, "controller-name", ingress.ControllerName, "The spec.controller of the IngressClasses handled by this controller.")
+	flag.StringVar(&ingress.IngressClassName, "ingress-class", ingress.IngressClassName, "The kubernetes.io/ingress.class annotation handled when no IngressClass of that name exists.")
+	flag.StringVar(&awssession.Region, "region", "", "The AWS region of the stacks, defaults to AWS_REGION, AWS_DEFAULT_REGION, the shared config and then the ec2 instance metadata.")
+	flag.StringVar(&awssession.EndpointURL, "aws-endpoint-url", "", "Sends the calls of every AWS service to this endpoint, e.g. http://localhost:4566 for LocalStack.")
+	flag.StringVar(&awsEndpoints, "aws-endpoints", "", "Comma separated service=url endpoint overrides, e.g. cloudformation=http://localhost:4566. Services are "+strings.Join(awssession.EndpointServices, ", ")+".")
+	flag.BoolVar(&ingress.DryRun, "dry-run", false, "Plans the stack changes of all ingresses in CloudFormation change sets without executing them.")
+	flag.StringVar(&ingress.ClusterID, "cluster-id", ingress.ClusterID, "Tells apart the CloudFormation stacks of clusters sharing an AWS account, it is hashed into the stack names.")
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		ingress.IngressClassParametersNamespace = ns
+	}
+	flag.StringVar(&ingress.IngressClassParametersNamespace, "ingress-class-parameters-namespace", ingress.IngressClassParametersNamespace, "The namespace of the ConfigMaps referenced by IngressClass parameters.")
 	flag.Parse()
-	logf.SetLogger(logf.ZapLogger(false))
+	logf.SetLogger(zap.New())
 	log := logf.Log.WithName("entrypoint")
 
+	if defaultsFile != "" {
+		if err := ingress.LoadDefaultsFile(defaultsFile); err != nil {
+			log.Error(err, "unable to load --defaults-file")
+			os.Exit(1)
+		}
+	}
+
+	selector, err := labels.Parse(ingressSelector)
+	if err != nil {
+		log.Error(err, "invalid --ingress-selector")
+		os.Exit(1)
+	}
+	ingress.IngressSelector = selector
+
+	// The IngressClass parameters are read from the namespace of the controller whether it serves its ingresses or not
+	var cacheNamespaces []string
+	for _, ns := range strings.Split(watchNamespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			ingress.WatchNamespaces = append(ingress.WatchNamespaces, ns)
+			cacheNamespaces = append(cacheNamespaces, ns)
+		}
+	}
+	if len(cacheNamespaces) > 0 && ingress.IngressClassParametersNamespace != "" && !contains(cacheNamespaces, ingress.IngressClassParametersNamespace) {
+		cacheNamespaces = append(cacheNamespaces, ingress.IngressClassParametersNamespace)
+	}
+
+	endpoints, err := awssession.ParseEndpoints(awsEndpoints)
+	if err != nil {
+		log.Error(err, "invalid --aws-endpoints")
+		os.Exit(1)
+	}
+	awssession.Endpoints = endpoints
+
+	// Resolve the region and credentials up front so that a misconfiguration stops the controller with a clear error
+	log.Info("setting up AWS session")
+	if _, err := awssession.New(logging.New()); err != nil {
+		log.Error(err, "unable to set up AWS session")
+		os.Exit(1)
+	}
+
 	// Get a config to talk to the apiserver
 	log.Info("setting up client for manager")
 	cfg, err := config.GetConfig()
@@ -45,8 +136,24 @@ func main() {
 
 	// Create a new Cmd to provide shared dependencies and start components
 	log.Info("setting up manager")
+	// The lease is released on shutdown so that the next leader takes over without waiting for it to expire. The
+	// webhook is served by every replica, only the controllers wait for the lease.
 	mgr, err := manager.New(cfg, manager.Options{
-		MetricsBindAddress: metricsAddr,
+		MetricsBindAddress:            metricsAddr,
+		Port:                          webhookPort,
+		CertDir:                       webhookCertDir,
+		LeaderElection:                leaderElect,
+		LeaderElectionResourceLock:    resourcelock.LeasesResourceLock,
+		LeaderElectionNamespace:       leaderElectionNamespace,
+		LeaderElectionID:              leaderElectionID,
+		LeaderElectionReleaseOnCancel: true,
+		LeaseDuration:                 &leaseDuration,
+		RenewDeadline:                 &renewDeadline,
+		RetryPeriod:                   &retryPeriod,
+		GracefulShutdownTimeout:       &gracefulShutdownTimeout,
+		NewCache:                      namespacedcache.New(cacheNamespaces),
+		// Only the metadata of Secrets is watched, caching all of them would not fit the memory limit
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}},
 	})
 
 	if err != nil {
@@ -54,6 +161,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup Scheme for all resources
+	log.Info("setting up scheme")
+	if err := apis.AddToScheme(mgr.GetScheme()); err != nil {
+		log.Error(err, "unable add APIs to scheme")
+		os.Exit(1)
+	}
+
 	// Setup all Controllers
 	log.Info("Setting up controller")
 	if err := controller.AddToManager(mgr); err != nil {
@@ -61,10 +175,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	log.Info("setting up webhooks")
-	if err := webhook.AddToManager(mgr); err != nil {
-		log.Error(err, "unable to register webhooks to the manager")
-		os.Exit(1)
+	if enableWebhooks {
+		log.Info("setting up webhooks")
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "unable to register webhooks to the manager")
+			os.Exit(1)
+		}
 	}
 
 	// Start the Cmd
@@ -74,3 +190,12 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
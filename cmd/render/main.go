@@ -0,0 +1,146 @@
+// Command render prints the CloudFormation templates and the nginx.conf the controller generates for an Ingress,
+// without cluster or AWS access. The Ingress, and the Services, APIGatewayConfig, IngressClass and ConfigMaps it
+// references, are read from manifests and what the controller looks up in AWS from a network file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/controller/ingress"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/network"
+	"sigs.k8s.io/yaml"
+)
+
+// networkFile stands in for the worker nodes, the reverse proxy Service and the resources the controller looks up
+// in AWS
+type networkFile struct {
+	VpcID            string            `json:"vpcId"`
+	VpcCIDR          string            `json:"vpcCidr"`
+	InstanceIDs      []string          `json:"instanceIds"`
+	SecurityGroupIDs []string          `json:"securityGroupIds"`
+	SubnetIDs        []string          `json:"subnetIds"`
+	NodePort         int               `json:"nodePort"`
+	CertificateArns  map[string]string `json:"certificateArns"`
+	StackOutputs     map[string]string `json:"stackOutputs"`
+}
+
+// outputs are the values of --output
+var outputs = []string{"all", "template", "route53", "nginx"}
+
+func main() {
+	var manifestsPath string
+	var networkPath string
+	var defaultsFile string
+	var output string
+	flag.StringVar(&manifestsPath, "f", "", "YAML or JSON manifests of the Ingress and the Services, APIGatewayConfig, IngressClass and ConfigMaps it references, - for stdin.")
+	flag.StringVar(&networkPath, "network", "", "YAML or JSON file of the VPC, worker nodes, reverse proxy node port, TLS certificate ARNs and main stack outputs.")
+	flag.StringVar(&defaultsFile, "defaults-file", "", "YAML file of annotation defaults, like --defaults-file of the manager.")
+	flag.StringVar(&ingress.DefaultsConfigMapName, "defaults-configmap", ingress.DefaultsConfigMapName, "ConfigMap of the manifests of annotation defaults, like --defaults-configmap of the manager.")
+	flag.StringVar(&ingress.ControllerName, "controller-name", ingress.ControllerName, "The spec.controller of the IngressClasses handled by the controller.")
+	flag.StringVar(&ingress.IngressClassName, "ingress-class", ingress.IngressClassName, "The kubernetes.io/ingress.class annotation handled when no IngressClass of that name exists.")
+	flag.StringVar(&ingress.IngressClassParametersNamespace, "ingress-class-parameters-namespace", ingress.IngressClassParametersNamespace, "The namespace of the ConfigMaps referenced by IngressClass parameters, ConfigMaps of the manifests without namespace are in it.")
+	flag.StringVar(&output, "output", "all", "What to print: all, template, route53 or nginx.")
+	flag.Parse()
+
+	if err := run(os.Stdout, manifestsPath, networkPath, defaultsFile, output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer, manifestsPath, networkPath, defaultsFile, output string) error {
+	if manifestsPath == "" || networkPath == "" {
+		return fmt.Errorf("-f and --network are required")
+	}
+	if !contains(outputs, output) {
+		return fmt.Errorf("unknown output %q, must be one of all, template, route53 or nginx", output)
+	}
+
+	if defaultsFile != "" {
+		if err := ingress.LoadDefaultsFile(defaultsFile); err != nil {
+			return err
+		}
+	}
+
+	b, err := readFile(manifestsPath)
+	if err != nil {
+		return err
+	}
+	in, err := ingress.ReadManifests(b)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %v", manifestsPath, err)
+	}
+
+	b, err = ioutil.ReadFile(networkPath)
+	if err != nil {
+		return err
+	}
+	nf := &networkFile{}
+	if err := yaml.UnmarshalStrict(b, nf); err != nil {
+		return fmt.Errorf("unable to parse %s: %v", networkPath, err)
+	}
+	if nf.VpcID == "" || nf.VpcCIDR == "" {
+		return fmt.Errorf("vpcId and vpcCidr of %s are required", networkPath)
+	}
+
+	in.Network = &network.Network{
+		InstanceIDs:      nf.InstanceIDs,
+		SecurityGroupIDs: nf.SecurityGroupIDs,
+		SubnetIDs:        nf.SubnetIDs,
+		Vpc:              &ec2.Vpc{VpcId: aws.String(nf.VpcID), CidrBlock: aws.String(nf.VpcCIDR)},
+	}
+	in.NodePort = nf.NodePort
+	in.CertificateArns = nf.CertificateArns
+	in.StackOutputs = nf.StackOutputs
+
+	rendered, err := ingress.Render(in)
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case "template":
+		_, err = fmt.Fprint(w, rendered.Template)
+	case "route53":
+		_, err = fmt.Fprint(w, rendered.Route53Template)
+	case "nginx":
+		_, err = fmt.Fprint(w, rendered.NginxConfig)
+	default:
+		_, err = fmt.Fprintf(w, "# CloudFormation template\n%s\n# Route53 CloudFormation template\n%s\n# nginx.conf\n%s", rendered.Template, orNone(rendered.Route53Template), rendered.NginxConfig)
+	}
+
+	return err
+}
+
+func readFile(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+
+	return ioutil.ReadFile(path)
+}
+
+// orNone marks the Route53 stack of ingresses without hosted zone as not created
+func orNone(template string) string {
+	if template == "" {
+		return "# none, the ingress has no hosted zone\n"
+	}
+
+	return template
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
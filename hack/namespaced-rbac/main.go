@@ -0,0 +1,145 @@
+// Command namespaced-rbac turns the ClusterRole of the manager into Roles for the namespaces a controller started
+// with --watch-namespaces serves, keeping only the cluster scoped resources in a ClusterRole. It prints the
+// manifests, `make deploy-namespaced` applies them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// clusterScoped are the resources of the ClusterRole that can't be granted by a Role
+var clusterScoped = map[string]bool{
+	"nodes":                           true,
+	"ingressclasses":                  true,
+	"mutatingwebhookconfigurations":   true,
+	"validatingwebhookconfigurations": true,
+}
+
+func main() {
+	var rolePath string
+	var namespaces string
+	var controllerNamespace string
+	var name string
+	var serviceAccount string
+	flag.StringVar(&rolePath, "role", "config/rbac/rbac_role.yaml", "The ClusterRole generated from the kubebuilder:rbac markers.")
+	flag.StringVar(&namespaces, "namespaces", "", "Comma separated namespaces passed to --watch-namespaces.")
+	flag.StringVar(&controllerNamespace, "controller-namespace", "amzn-apigateway-ingress-controller-system", "The namespace the controller runs in, it holds the leader election Lease and IngressClass parameters.")
+	flag.StringVar(&name, "name", "amzn-apigateway-ingress-controller-manager-role", "The name of the generated roles and bindings.")
+	flag.StringVar(&serviceAccount, "service-account", "default", "The service account of the controller.")
+	flag.Parse()
+
+	if err := run(rolePath, namespaces, controllerNamespace, name, serviceAccount); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(rolePath, namespaces, controllerNamespace, name, serviceAccount string) error {
+	b, err := ioutil.ReadFile(rolePath)
+	if err != nil {
+		return err
+	}
+	role := &rbacv1.ClusterRole{}
+	if err := yaml.Unmarshal(b, role); err != nil {
+		return fmt.Errorf("unable to parse %s: %v", rolePath, err)
+	}
+
+	var targets []string
+	for _, ns := range strings.Split(namespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" && !contains(targets, ns) {
+			targets = append(targets, ns)
+		}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("--namespaces is required")
+	}
+	if !contains(targets, controllerNamespace) {
+		targets = append(targets, controllerNamespace)
+	}
+
+	clusterRules, namespacedRules := splitRules(role.Rules)
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: serviceAccount, Namespace: controllerNamespace}}
+
+	objects := []interface{}{
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Rules:      clusterRules,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+			Subjects:   subjects,
+		},
+	}
+	for _, ns := range targets {
+		objects = append(objects,
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+				Rules:      namespacedRules,
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+				Subjects:   subjects,
+			},
+		)
+	}
+
+	for i, object := range objects {
+		b, err := yaml.Marshal(object)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println("---")
+		}
+		fmt.Print(string(b))
+	}
+
+	return nil
+}
+
+// splitRules separates the cluster scoped resources of the rules, subresources included, from the namespaced ones
+func splitRules(rules []rbacv1.PolicyRule) ([]rbacv1.PolicyRule, []rbacv1.PolicyRule) {
+	var clusterRules, namespacedRules []rbacv1.PolicyRule
+	for _, rule := range rules {
+		cluster, namespaced := rule, rule
+		cluster.Resources, namespaced.Resources = nil, nil
+		for _, resource := range rule.Resources {
+			if clusterScoped[strings.SplitN(resource, "/", 2)[0]] {
+				cluster.Resources = append(cluster.Resources, resource)
+			} else {
+				namespaced.Resources = append(namespaced.Resources, resource)
+			}
+		}
+		if len(cluster.Resources) > 0 {
+			clusterRules = append(clusterRules, cluster)
+		}
+		if len(namespaced.Resources) > 0 {
+			namespacedRules = append(namespacedRules, namespaced)
+		}
+	}
+
+	return clusterRules, namespacedRules
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,8 @@
+// +build tools
+
+// Package tools tracks the build tools used by the Makefile so they are pinned in go.mod and vendored
+package tools
+
+import (
+	_ "sigs.k8s.io/controller-tools/cmd/controller-gen"
+)
@@ -0,0 +1,230 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// APIGatewayConfigSpec defines the API Gateway configuration of the ingresses referencing it. Each field replaces
+// the JSON annotation of the same content.
+type APIGatewayConfigSpec struct {
+	// AWSAPIDefinitions replaces the aws-api-configs annotation
+	// +optional
+	AWSAPIDefinitions []AWSAPIDefinition `json:"awsAPIDefinitions,omitempty"`
+	// PublicResources replaces the public-resources annotation
+	// +optional
+	PublicResources []APIResource `json:"publicResources,omitempty"`
+	// UsagePlans replaces the api-key-based-usage-plans annotation
+	// +optional
+	UsagePlans []UsagePlanConfig `json:"usagePlans,omitempty"`
+	// Canary replaces the canary-percent and canary-stage-variables annotations
+	// +optional
+	Canary *CanaryConfig `json:"canary,omitempty"`
+}
+
+// CanaryConfig shifts part of the stage traffic to new deployments until they are promoted or rolled back
+type CanaryConfig struct {
+	// PercentTraffic is the share of the stage traffic the canary deployment receives
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	PercentTraffic float64 `json:"percentTraffic"`
+	// StageVariableOverrides are the stage variables that differ for the canary deployment
+	// +optional
+	StageVariableOverrides map[string]string `json:"stageVariableOverrides,omitempty"`
+}
+
+type UsagePlanConfig struct {
+	// +kubebuilder:validation:MinLength=1
+	PlanName string `json:"planName"`
+	// +optional
+	Description string `json:"description,omitempty"`
+	// +optional
+	APIKeys []APIKeyConfig `json:"apiKeys,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	QuotaLimit int `json:"quotaLimit,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	QuotaOffset int `json:"quotaOffset,omitempty"`
+	// +kubebuilder:validation:Enum=DAY;WEEK;MONTH
+	// +optional
+	QuotaPeriod string `json:"quotaPeriod,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ThrottleBurstLimit int `json:"throttleBurstLimit,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ThrottleRateLimit float64 `json:"throttleRateLimit,omitempty"`
+	// +optional
+	MethodThrottlingParameters []MethodThrottlingParameters `json:"methodThrottlingParameters,omitempty"`
+}
+
+type APIKeyConfig struct {
+	// +optional
+	CustomerID string `json:"customerID,omitempty"`
+	// +optional
+	GenerateDistinctID bool `json:"generateDistinctID,omitempty"`
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+type MethodThrottlingParameters struct {
+	// Path is the resource path and method the limits apply to, e.g. /api/v1/GET
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BurstLimit int `json:"burstLimit,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RateLimit float64 `json:"rateLimit,omitempty"`
+}
+
+type APIResource struct {
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// +optional
+	CachingEnabled bool `json:"cachingEnabled,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CacheTTLInSeconds int `json:"cacheTTLInSeconds,omitempty"`
+	// +optional
+	Methods []Method `json:"methods,omitempty"`
+	// +optional
+	ConstantPathParams []ConstantParam `json:"constantPathParams,omitempty"`
+	// +optional
+	ConstantQueryParams []ConstantParam `json:"constantQueryParams,omitempty"`
+	// +optional
+	ConstantHeaderParams []ConstantParam `json:"constantHeaderParams,omitempty"`
+	// +optional
+	PathParams []Param `json:"pathParams,omitempty"`
+	// +optional
+	QueryParams []Param `json:"queryParams,omitempty"`
+	// +optional
+	HeaderParams []Param `json:"headerParams,omitempty"`
+	// Type is the integration type, the default proxies to the ingress backends and Lambda needs LambdaArn
+	// +kubebuilder:validation:Enum=Lambda;Mock
+	// +optional
+	Type string `json:"type,omitempty"`
+	// +optional
+	LambdaArn string `json:"lambdaArn,omitempty"`
+}
+
+type Method struct {
+	// +kubebuilder:validation:Enum=ANY;DELETE;GET;HEAD;OPTIONS;PATCH;POST;PUT
+	Method string `json:"method"`
+	// +optional
+	APIKeyEnabled bool `json:"apiKeyEnabled,omitempty"`
+	// +optional
+	AuthorizationEnabled bool `json:"authorizationEnabled,omitempty"`
+	// AuthorizerIndex is the position of the authorizer in the authorizers of the AWSAPIDefinition
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	AuthorizerIndex int `json:"authorizerIndex,omitempty"`
+	// +optional
+	AuthorizationScopes []string `json:"authorizationScopes,omitempty"`
+}
+
+type AWSAPIDefinition struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// +kubebuilder:validation:MinLength=1
+	Context string `json:"context"`
+	// +optional
+	AuthenticationEnabled bool `json:"authenticationEnabled,omitempty"`
+	// +optional
+	APIKeyEnabled bool `json:"apiKeyEnabled,omitempty"`
+	// +optional
+	AuthorizationEnabled bool `json:"authorizationEnabled,omitempty"`
+	// +optional
+	UsagePlans []UsagePlanConfig `json:"usagePlans,omitempty"`
+	// +optional
+	Authorizers []AWSAPIAuthorizer `json:"authorizers,omitempty"`
+	// +optional
+	APIs []APIResource `json:"apis,omitempty"`
+	// +optional
+	BinaryMediaTypes []string `json:"binaryMediaTypes,omitempty"`
+	// +kubebuilder:validation:Enum=OFF;ERROR;INFO
+	// +optional
+	LoggingLevel string `json:"loggingLevel,omitempty"`
+}
+
+type AWSAPIAuthorizer struct {
+	// +optional
+	IdentitySource string `json:"identitySource,omitempty"`
+	// +kubebuilder:validation:Enum=TOKEN;COGNITO_USER_POOLS;REQUEST
+	// +optional
+	AuthorizerType string `json:"authorizerType,omitempty"`
+	// +optional
+	AuthorizerAuthType string `json:"authorizerAuthType,omitempty"`
+	// +kubebuilder:validation:MinLength=1
+	AuthorizerName string `json:"authorizerName"`
+	// +optional
+	IdentityValidationExpression string `json:"identityValidationExpression,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=3600
+	// +optional
+	AuthorizerResultTTLInSeconds int `json:"authorizerResultTTLInSeconds,omitempty"`
+	// AuthorizerURI is the ARN of the Lambda authorizer
+	// +optional
+	AuthorizerURI string `json:"lambdaArn,omitempty"`
+	// ProviderARNs are the Cognito user pools of COGNITO_USER_POOLS authorizers
+	// +optional
+	ProviderARNs []string `json:"providerARNs,omitempty"`
+}
+
+type Param struct {
+	// +kubebuilder:validation:MinLength=1
+	Param string `json:"param"`
+	// +optional
+	Required bool `json:"required,omitempty"`
+	// +optional
+	MappingParam string `json:"mappingParam,omitempty"`
+}
+
+type ConstantParam struct {
+	// +kubebuilder:validation:MinLength=1
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// APIGatewayConfig is the API Gateway configuration referenced by ingresses through the
+// apigateway.ingress.kubernetes.io/config annotation
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=apigwconfig
+type APIGatewayConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec APIGatewayConfigSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// APIGatewayConfigList contains a list of APIGatewayConfig
+type APIGatewayConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []APIGatewayConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&APIGatewayConfig{}, &APIGatewayConfigList{})
+}
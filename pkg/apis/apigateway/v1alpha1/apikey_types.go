@@ -0,0 +1,72 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// APIKeySpec defines the desired state of APIKey
+type APIKeySpec struct {
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Enabled defaults to true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// CustomerID is the AWS Marketplace customer identifier
+	// +optional
+	CustomerID string `json:"customerID,omitempty"`
+	// +optional
+	GenerateDistinctID bool `json:"generateDistinctID,omitempty"`
+}
+
+// APIKeyStatus defines the observed state of APIKey
+type APIKeyStatus struct {
+	// ID is the id of the API Gateway key
+	// +optional
+	ID string `json:"id,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// APIKey is an API Gateway key associated with usage plans
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=apigwkey
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="ID",type="string",JSONPath=".status.id"
+type APIKey struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   APIKeySpec   `json:"spec,omitempty"`
+	Status APIKeyStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// APIKeyList contains a list of APIKey
+type APIKeyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []APIKey `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&APIKey{}, &APIKeyList{})
+}
@@ -0,0 +1,116 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UsagePlanSpec defines the desired state of UsagePlan
+type UsagePlanSpec struct {
+	// +optional
+	Description string `json:"description,omitempty"`
+	// +optional
+	Quota *QuotaSettings `json:"quota,omitempty"`
+	// +optional
+	Throttle *ThrottleSettings `json:"throttle,omitempty"`
+	// APIStages are the stages of the APIs managed for ingresses the plan applies to
+	// +optional
+	APIStages []APIStage `json:"apiStages,omitempty"`
+	// APIKeys are the APIKeys in the namespace of the plan that are associated with it
+	// +optional
+	APIKeys []corev1.LocalObjectReference `json:"apiKeys,omitempty"`
+}
+
+type QuotaSettings struct {
+	// +kubebuilder:validation:Minimum=0
+	Limit int `json:"limit"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Offset int `json:"offset,omitempty"`
+	// +kubebuilder:validation:Enum=DAY;WEEK;MONTH
+	Period string `json:"period"`
+}
+
+type ThrottleSettings struct {
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BurstLimit int `json:"burstLimit,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RateLimit float64 `json:"rateLimit,omitempty"`
+}
+
+// APIStage selects the stage of the APIs of an ingress. Ingresses with several hosts have one API per host,
+// the plan applies to the stage of each of them.
+type APIStage struct {
+	// Ingress is the name of an ingress in the namespace of the plan
+	// +kubebuilder:validation:MinLength=1
+	Ingress string `json:"ingress"`
+	// Stage is the stage-name of the ingress
+	// +kubebuilder:validation:MinLength=1
+	Stage string `json:"stage"`
+}
+
+// UsagePlanStatus defines the observed state of UsagePlan
+type UsagePlanStatus struct {
+	// ID is the id of the API Gateway usage plan
+	// +optional
+	ID string `json:"id,omitempty"`
+	// APIStages are the API Gateway stages the plan is associated with
+	// +optional
+	APIStages []APIStageStatus `json:"apiStages,omitempty"`
+	// APIKeyIDs are the ids of the API Gateway keys associated with the plan
+	// +optional
+	APIKeyIDs []string `json:"apiKeyIDs,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+type APIStageStatus struct {
+	APIID string `json:"apiID"`
+	Stage string `json:"stage"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// UsagePlan is an API Gateway usage plan managed independently of the ingresses whose APIs it applies to
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=apigwusageplan
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="ID",type="string",JSONPath=".status.id"
+type UsagePlan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UsagePlanSpec   `json:"spec,omitempty"`
+	Status UsagePlanStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// UsagePlanList contains a list of UsagePlan
+type UsagePlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UsagePlan `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UsagePlan{}, &UsagePlanList{})
+}
@@ -0,0 +1,169 @@
+package awssession
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"go.uber.org/zap"
+)
+
+// Region overrides the region of the environment and the shared config, it is set with --region
+var Region = ""
+
+// EndpointURL sends the calls of every service to one endpoint, e.g. LocalStack, it is set with --aws-endpoint-url
+var EndpointURL = ""
+
+// Endpoints overrides the endpoint of single services by endpoint id, taking precedence over EndpointURL. It is set
+// with --aws-endpoints.
+var Endpoints = map[string]string{}
+
+// EndpointServices are the endpoint ids of the services the controller calls
+var EndpointServices = []string{
+	"acm",
+	"apigateway",
+	"autoscaling",
+	"cloudformation",
+	"ec2",
+	"elasticloadbalancing",
+	"s3",
+	"sts",
+}
+
+var (
+	once    sync.Once
+	sess    *session.Session
+	sessErr error
+)
+
+// New returns the session shared by all controllers. The region is taken from Region, then AWS_REGION,
+// AWS_DEFAULT_REGION and the shared config, and only then from the ec2 instance metadata. Credentials come from the
+// default chain: environment, IRSA web identity, shared config and container or instance roles.
+func New(logger *zap.Logger) (*session.Session, error) {
+	once.Do(func() {
+		sess, sessErr = newSession(logger, Region)
+	})
+
+	return sess, sessErr
+}
+
+func newSession(logger *zap.Logger, region string) (*session.Session, error) {
+	config := aws.Config{}
+	if region != "" {
+		config.Region = aws.String(region)
+	}
+	if EndpointURL != "" {
+		if err := validateEndpointURL(EndpointURL); err != nil {
+			return nil, fmt.Errorf("invalid AWS endpoint URL: %v", err)
+		}
+	}
+	if EndpointURL != "" || len(Endpoints) > 0 {
+		config.EndpointResolver = endpointResolver(logger, EndpointURL, Endpoints)
+		// Emulators serve buckets on their own host rather than on subdomains
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	s, err := session.NewSessionWithOptions(session.Options{
+		Config:            config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create AWS session: %v", err)
+	}
+
+	if aws.StringValue(s.Config.Region) == "" {
+		logger.Info("no region configured, fetching it from ec2 metadata")
+		region, err := ec2metadata.New(s).Region()
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine the AWS region, set --region or AWS_REGION: %v", err)
+		}
+		s = s.Copy(&aws.Config{Region: aws.String(region)})
+	}
+
+	creds, err := s.Config.Credentials.Get()
+	if err != nil {
+		return nil, fmt.Errorf("unable to find AWS credentials, configure IRSA, an instance role or AWS_ACCESS_KEY_ID: %v", err)
+	}
+
+	logger.Info("creating AWS api session", zap.String("region", aws.StringValue(s.Config.Region)), zap.String("credentials", creds.ProviderName))
+	return s, nil
+}
+
+// ParseEndpoints parses comma separated service=url pairs, the services being endpoint ids of EndpointServices
+func ParseEndpoints(value string) (map[string]string, error) {
+	overrides := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("endpoint %q must be service=url", pair)
+		}
+		service, endpoint := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if !contains(EndpointServices, service) {
+			return nil, fmt.Errorf("unknown service %q, must be one of %s", service, strings.Join(EndpointServices, ", "))
+		}
+		if err := validateEndpointURL(endpoint); err != nil {
+			return nil, fmt.Errorf("endpoint of %s: %v", service, err)
+		}
+		overrides[service] = endpoint
+	}
+
+	return overrides, nil
+}
+
+func validateEndpointURL(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q must be an http or https URL", endpoint)
+	}
+
+	return nil
+}
+
+// endpointResolver resolves the overridden services to their endpoint, signed for the region of the client, and
+// all others like the SDK does. The instance metadata is never overridden.
+func endpointResolver(logger *zap.Logger, all string, overrides map[string]string) endpoints.Resolver {
+	services := make([]string, 0, len(overrides))
+	for service := range overrides {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	fields := []zap.Field{zap.String("all", all)}
+	for _, service := range services {
+		fields = append(fields, zap.String(service, overrides[service]))
+	}
+	logger.Info("overriding AWS endpoints", fields...)
+
+	return endpoints.ResolverFunc(func(service, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+		endpoint, ok := overrides[service]
+		if !ok && contains(EndpointServices, service) {
+			endpoint = all
+		}
+		if endpoint == "" {
+			return endpoints.DefaultResolver().EndpointFor(service, region, opts...)
+		}
+
+		return endpoints.ResolvedEndpoint{URL: endpoint, SigningRegion: region}, nil
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
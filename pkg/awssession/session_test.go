@@ -0,0 +1,145 @@
+package awssession
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+)
+
+func Test_newSession(t *testing.T) {
+	tests := []struct {
+		name       string
+		region     string
+		env        map[string]string
+		wantRegion string
+		wantErr    bool
+	}{
+		{
+			name:       "flag wins over environment",
+			region:     "eu-west-1",
+			env:        map[string]string{"AWS_REGION": "us-east-2"},
+			wantRegion: "eu-west-1",
+		},
+		{
+			name:       "AWS_REGION",
+			env:        map[string]string{"AWS_REGION": "us-east-2"},
+			wantRegion: "us-east-2",
+		},
+		{
+			name:       "AWS_DEFAULT_REGION",
+			env:        map[string]string{"AWS_DEFAULT_REGION": "ap-southeast-1"},
+			wantRegion: "ap-southeast-1",
+		},
+		{
+			name:    "no region without metadata",
+			wantErr: true,
+		},
+		{
+			name:    "no credentials",
+			region:  "eu-west-1",
+			env:     map[string]string{"AWS_ACCESS_KEY_ID": ""},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{
+				"AWS_REGION":                  "",
+				"AWS_DEFAULT_REGION":          "",
+				"AWS_ACCESS_KEY_ID":           "AKID",
+				"AWS_SECRET_ACCESS_KEY":       "SECRET",
+				"AWS_CONFIG_FILE":             os.DevNull,
+				"AWS_SHARED_CREDENTIALS_FILE": os.DevNull,
+				"AWS_EC2_METADATA_DISABLED":   "true",
+			}
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			for k, v := range env {
+				defer func(k, v string, ok bool) {
+					if ok {
+						os.Setenv(k, v)
+					} else {
+						os.Unsetenv(k)
+					}
+				}(k, os.Getenv(k), os.Getenv(k) != "")
+				os.Setenv(k, v)
+			}
+
+			got, err := newSession(zap.NewNop(), tt.region)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSession() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && aws.StringValue(got.Config.Region) != tt.wantRegion {
+				t.Errorf("newSession() region = %v, want %v", aws.StringValue(got.Config.Region), tt.wantRegion)
+			}
+		})
+	}
+}
+
+func TestParseEndpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: map[string]string{}},
+		{
+			name:  "several",
+			value: "cloudformation=http://localhost:4566, sts=https://sts.local",
+			want: map[string]string{
+				"cloudformation": "http://localhost:4566",
+				"sts":            "https://sts.local",
+			},
+		},
+		{name: "unknown service", value: "route66=http://localhost:4566", wantErr: true},
+		{name: "missing url", value: "ec2", wantErr: true},
+		{name: "invalid url", value: "ec2=localhost:4566", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEndpoints(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEndpoints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEndpoints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_endpointResolver(t *testing.T) {
+	resolver := endpointResolver(zap.NewNop(), "http://localhost:4566", map[string]string{"s3": "http://localhost:9000"})
+
+	tests := []struct {
+		service string
+		want    string
+	}{
+		{service: "cloudformation", want: "http://localhost:4566"},
+		{service: "s3", want: "http://localhost:9000"},
+		{service: "ec2metadata", want: ""},
+		{service: "lambda", want: "https://lambda.eu-west-1.amazonaws.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			got, err := resolver.EndpointFor(tt.service, "eu-west-1")
+			if tt.want == "" {
+				if got.URL == "http://localhost:4566" {
+					t.Errorf("EndpointFor() = %v, want the default endpoint", got.URL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EndpointFor() error = %v", err)
+			}
+			if got.URL != tt.want || got.SigningRegion != "eu-west-1" {
+				t.Errorf("EndpointFor() = %v %v, want %v eu-west-1", got.URL, got.SigningRegion, tt.want)
+			}
+		})
+	}
+}
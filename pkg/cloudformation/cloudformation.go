@@ -17,7 +17,7 @@ import (
 	"github.com/awslabs/goformation/v4/cloudformation/tags"
 	"github.com/awslabs/goformation/v4/cloudformation/wafv2"
 
-	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
 )
 
 //const is constance values for resource naming used to build cf templates
@@ -34,6 +34,8 @@ const (
 	APIEmptyModelResourceName               = "RestAPIEmptyModel"
 	CustomDomainResourceName                = "CustomDomain"
 	CustomDomainBasePathMappingResourceName = "CustomDomainBasePathMapping"
+	HostCustomDomainResourceName            = "HostCustomDomain"
+	HostBasePathMappingResourceName         = "HostCustomDomainBasePathMapping"
 	DeploymentResourceName                  = "Deployment"
 	DistributionDomainNameResourceName      = "DistributionDomainName"
 	DistributionHostedZoneIdResourceName    = "DistributionHostedZoneId"
@@ -51,28 +53,13 @@ const (
 	Route53RecordResourceName               = "Route53RecordSet"
 	OutputKeyRestAPIID                      = "RestAPIID"
 	OutputKeyAPIGatewayEndpoint             = "APIGatewayEndpoint"
-	OutputKeyAPIEndpointType                = "APIGWEndpointType"
-	OutputKeyClientARNS                     = "ClientARNS"
-	OutputKeyCertARN                        = "SSLCertArn"
-	OutputKeyCustomDomain                   = "CustomDomainName"
-	OutputKeyCustomDomainBasePath           = "CustomDomainBasePath"
-	OutputMinimumCompressionSize            = "MinimumCompressionSize"
-	OutputKeyIngressRules                   = "IngressRules"
 	OutputKeyWAFEnabled                     = "WAFEnabled"
-	OutputKeyWAFRules                       = "WAFRules"
-	OutputKeyWAFScope                       = "WAFScope"
 	OutputKeyWAFAssociationCreated          = "WAFAssociation"
 	OutputKeyCustomDomainHostName           = "CustomDomainHostname"
 	OutputKeyCustomDomainHostedZoneID       = "CustomDomainHostedZoneID"
-	OutputKeyHostedZone                     = "HostedZone"
-	OutputKeyRequestTimeout                 = "RequestTimeout"
-	OutputKeyTLSPolicy                      = "TLSPolicy"
-	OutputKeyUsagePlans                     = "UsagePlansData"
-	OutputKeyCachingEnabled                 = "CachingEnabled"
-	OutputKeyCacheClusterSize               = "CachingSize"
-	OutputKeyAPIResources                   = "APIResources"
-	OutputKeyAWSAPIConfigs                  = "AWSAPIConfigs"
-	OutputLoggingLevel                      = "LoggingLevel"
+	OutputKeyHostCustomDomain               = "HostCustomDomainName"
+	OutputKeyHostCustomDomainHostName       = "HostCustomDomainHostname"
+	OutputKeyHostCustomDomainHostedZoneID   = "HostCustomDomainHostedZoneID"
 )
 
 func toLogicalName(idx int, parts []string) string {
@@ -126,12 +113,23 @@ func mapAPIGWMethodsAndResourcesFromDefinedPublicAPIs(resources []APIResource, r
 	return m
 }
 
-func mapApiGatewayMethodsAndResourcesFromPaths(paths []extensionsv1beta1.HTTPIngressPath, requestTimeout int, authorizationType string, index int, authorizers []AWSAPIAuthorizer, apiKeyEnabled bool) map[string]cfn.Resource {
+// isExactPath reports whether the ingress path must only match the exact request path.
+// Prefix and ImplementationSpecific paths are both mapped to a greedy {proxy+} resource.
+func isExactPath(path networkingv1.HTTPIngressPath) bool {
+	return path.PathType != nil && *path.PathType == networkingv1.PathTypeExact
+}
+
+// mapApiGatewayMethodsAndResourcesFromPaths maps ingress paths to resources and methods of the RestApi at index.
+// A non empty host is sent as Host header to the reverse proxy so it can route the request to the rule of that host.
+func mapApiGatewayMethodsAndResourcesFromPaths(paths []networkingv1.HTTPIngressPath, requestTimeout int, authorizationType string, index int, authorizers []AWSAPIAuthorizer, apiKeyEnabled bool, host string) map[string]cfn.Resource {
 	m := map[string]cfn.Resource{}
 
 	for _, path := range paths {
-		parts := strings.Split(path.Path, "/")
-		parts = append(parts, "{proxy+}")
+		parts := strings.Split(strings.TrimSuffix(path.Path, "/"), "/")
+		exact := isExactPath(path)
+		if !exact {
+			parts = append(parts, "{proxy+}")
+		}
 		for idx, part := range parts {
 			if idx == 0 {
 				continue
@@ -143,11 +141,20 @@ func mapApiGatewayMethodsAndResourcesFromPaths(paths []extensionsv1beta1.HTTPIng
 
 			resourceLogicalName := fmt.Sprintf("%s%s%d", APIResourceResourceName, toLogicalName(idx, parts), index)
 			m[resourceLogicalName] = buildAWSApiGatewayResource(ref, part, index)
+			// Exact paths only expose a method on the last path part, intermediate resources stay method-less
+			if exact && idx != len(parts)-1 {
+				continue
+			}
+			var method *apigateway.Method
 			if authorizers != nil {
-				m[fmt.Sprintf("%s%s%d", APIMethodResourceID, toLogicalName(idx, parts), index)] = buildAWSApiGatewayMethod(resourceLogicalName, toPath(idx, parts), requestTimeout, authorizationType, "ANY", APIResource{}, index, &authorizers[0], 0, apiKeyEnabled, nil)
+				method = buildAWSApiGatewayMethod(resourceLogicalName, toPath(idx, parts), requestTimeout, authorizationType, "ANY", APIResource{}, index, &authorizers[0], 0, apiKeyEnabled, nil)
 			} else {
-				m[fmt.Sprintf("%s%s%d", APIMethodResourceID, toLogicalName(idx, parts), index)] = buildAWSApiGatewayMethod(resourceLogicalName, toPath(idx, parts), requestTimeout, authorizationType, "ANY", APIResource{}, index, nil, 0, apiKeyEnabled, nil)
+				method = buildAWSApiGatewayMethod(resourceLogicalName, toPath(idx, parts), requestTimeout, authorizationType, "ANY", APIResource{}, index, nil, 0, apiKeyEnabled, nil)
+			}
+			if host != "" {
+				method.Integration.RequestParameters["integration.request.header.Host"] = fmt.Sprintf("'%s'", host)
 			}
+			m[fmt.Sprintf("%s%s%d", APIMethodResourceID, toLogicalName(idx, parts), index)] = method
 		}
 	}
 
@@ -540,7 +547,7 @@ func buildAWSEC2SecurityGroupIngresses(securityGroupIds []string, cidr string, n
 	return sgIngresses
 }
 
-func buildCustomDomainBasePathMapping(domainName string, stageName string, basePath string, index int) *apigateway.BasePathMapping {
+func buildCustomDomainBasePathMapping(domainName string, domainResourceName string, stageName string, basePath string, index int) *apigateway.BasePathMapping {
 	var r *apigateway.BasePathMapping
 	if basePath == "" {
 		r = &apigateway.BasePathMapping{
@@ -557,7 +564,7 @@ func buildCustomDomainBasePathMapping(domainName string, stageName string, baseP
 		}
 	}
 
-	r.AWSCloudFormationDependsOn = []string{fmt.Sprintf("%s%d", DeploymentResourceName, index), CustomDomainResourceName}
+	r.AWSCloudFormationDependsOn = []string{fmt.Sprintf("%s%d", DeploymentResourceName, index), domainResourceName}
 	return r
 }
 
@@ -770,16 +777,121 @@ func buildMethodThrottling(methodThrottlingParameters []MethodThrottlingParamete
 	return stageResources
 }
 
+// HostRule holds the paths of all ingress rules sharing a host
+type HostRule struct {
+	Host  string
+	Paths []networkingv1.HTTPIngressPath
+}
+
+// GroupRulesByHost merges the paths of the ingress rules by host, in the order the hosts first appear
+func GroupRulesByHost(rules []networkingv1.IngressRule) []HostRule {
+	var hostRules []HostRule
+	indexes := map[string]int{}
+	for _, rule := range rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		i, ok := indexes[rule.Host]
+		if !ok {
+			i = len(hostRules)
+			indexes[rule.Host] = i
+			hostRules = append(hostRules, HostRule{Host: rule.Host})
+		}
+		hostRules[i].Paths = append(hostRules[i].Paths, rule.HTTP.Paths...)
+	}
+
+	return hostRules
+}
+
+// MergeHostRules merges the paths of all hosts into a single host-less rule, dropping duplicates
+func MergeHostRules(hostRules []HostRule) HostRule {
+	merged := HostRule{}
+	seen := map[string]bool{}
+	for _, hostRule := range hostRules {
+		for _, path := range hostRule.Paths {
+			key := path.Path
+			if path.PathType != nil {
+				key = fmt.Sprintf("%s:%s", *path.PathType, path.Path)
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged.Paths = append(merged.Paths, path)
+		}
+	}
+
+	return merged
+}
+
+// customDomainAPIIndex returns the index of the RestApi the custom domain annotation maps to: the one of the rule
+// with the same host, else the one of the host-less rules, else the first one
+func customDomainAPIIndex(hostRules []HostRule, customDomainName string) int {
+	for i, hostRule := range hostRules {
+		if hostRule.Host == customDomainName {
+			return i
+		}
+	}
+	for i, hostRule := range hostRules {
+		if hostRule.Host == "" {
+			return i
+		}
+	}
+	return 0
+}
+
+// matchesTLSHost reports whether a TLS host, possibly a wildcard, covers the domain
+func matchesTLSHost(tlsHost string, domainName string) bool {
+	if tlsHost == domainName {
+		return true
+	}
+
+	return strings.HasPrefix(tlsHost, "*.") && strings.Count(domainName, ".") == strings.Count(tlsHost, ".") && strings.HasSuffix(domainName, tlsHost[1:])
+}
+
+// ResolveCertificateArn picks the certificate of the custom-domain-name domain. The certificate-arn annotation, which
+// is only meant for that domain, wins, then comes the imported TLS certificate of the host covering the domain and
+// last the one of a TLS entry without hosts.
+func ResolveCertificateArn(certificateArn string, tlsCertificateArns map[string]string, domainName string) string {
+	if certificateArn != "" {
+		return certificateArn
+	}
+
+	if arn := TLSHostCertificateArn(tlsCertificateArns, domainName); arn != "" {
+		return arn
+	}
+
+	return tlsCertificateArns[""]
+}
+
+// TLSHostCertificateArn returns the imported TLS certificate of the host covering the domain, exactly or as wildcard,
+// or "" if no TLS entry names it
+func TLSHostCertificateArn(tlsCertificateArns map[string]string, domainName string) string {
+	if arn, ok := tlsCertificateArns[domainName]; ok {
+		return arn
+	}
+
+	for host, arn := range tlsCertificateArns {
+		if host != "" && matchesTLSHost(host, domainName) {
+			return arn
+		}
+	}
+
+	return ""
+}
+
 //TemplateConfig is the structure of configuration used to provide data to build the cf template
 type TemplateConfig struct {
 	Network                *network.Network
-	Rule                   extensionsv1beta1.IngressRule
+	Rules                  []networkingv1.IngressRule
 	NodePort               int
 	StageName              string
 	Arns                   []string
 	CustomDomainName       string
 	CustomDomainBasePath   string
 	CertificateArn         string
+	TLSCertificateArns     map[string]string
 	APIEndpointType        string
 	WAFEnabled             bool
 	WAFRulesJSON           string
@@ -799,9 +911,16 @@ type TemplateConfig struct {
 // BuildAPIGatewayTemplateFromIngressRule generates the cloudformation template according to the config provided
 func BuildAPIGatewayTemplateFromIngressRule(cfg *TemplateConfig) *cfn.Template {
 	template := cfn.NewTemplate()
-	paths := cfg.Rule.IngressRuleValue.HTTP.Paths
 	publicAPIs := cfg.APIResources
 
+	// Without API definitions every host gets its own RestApi, otherwise the paths of all hosts are merged
+	hostRules := GroupRulesByHost(cfg.Rules)
+	paths := MergeHostRules(hostRules).Paths
+	hostRouting := (cfg.AWSAPIDefinitions == nil || len(cfg.AWSAPIDefinitions) == 0) && (publicAPIs == nil || len(publicAPIs) == 0)
+	if !hostRouting || len(hostRules) == 0 {
+		hostRules = []HostRule{{Paths: paths}}
+	}
+
 	//Making default type edge
 	if cfg.APIEndpointType == "" {
 		cfg.APIEndpointType = "EDGE"
@@ -830,12 +949,15 @@ func BuildAPIGatewayTemplateFromIngressRule(cfg *TemplateConfig) *cfn.Template {
 	var apiSize int
 	if cfg.AWSAPIDefinitions != nil && len(cfg.AWSAPIDefinitions) > 0 {
 		apiSize = len(cfg.AWSAPIDefinitions)
+	} else if hostRouting {
+		apiSize = len(hostRules)
 	} else {
 		apiSize = 1
 	}
 
-	if cfg.CustomDomainName != "" && cfg.CertificateArn != "" {
-		customDomain := buildCustomDomain(cfg.CustomDomainName, cfg.CertificateArn, cfg.APIEndpointType, cfg.TLSPolicy)
+	customDomainCertificateArn := ResolveCertificateArn(cfg.CertificateArn, cfg.TLSCertificateArns, cfg.CustomDomainName)
+	if cfg.CustomDomainName != "" && customDomainCertificateArn != "" {
+		customDomain := buildCustomDomain(cfg.CustomDomainName, customDomainCertificateArn, cfg.APIEndpointType, cfg.TLSPolicy)
 		template.Resources[CustomDomainResourceName] = customDomain
 	}
 
@@ -871,12 +993,12 @@ func BuildAPIGatewayTemplateFromIngressRule(cfg *TemplateConfig) *cfn.Template {
 		} else if cfg.AWSAPIDefinitions != nil && len(cfg.AWSAPIDefinitions) > 0 && publicAPIs != nil && len(publicAPIs) > 0 {
 			resourceMap = mapAPIGWMethodsAndResourcesFromDefinedPublicAPIs(publicAPIs, cfg.RequestTimeout, authorizationType, i, cfg.AWSAPIDefinitions[i].Authorizers)
 		} else if cfg.AWSAPIDefinitions != nil && len(cfg.AWSAPIDefinitions) > 0 {
-			resourceMap = mapApiGatewayMethodsAndResourcesFromPaths(paths, cfg.RequestTimeout, authorizationType, i, cfg.AWSAPIDefinitions[i].Authorizers, cfg.AWSAPIDefinitions[i].APIKeyEnabled)
+			resourceMap = mapApiGatewayMethodsAndResourcesFromPaths(paths, cfg.RequestTimeout, authorizationType, i, cfg.AWSAPIDefinitions[i].Authorizers, cfg.AWSAPIDefinitions[i].APIKeyEnabled, "")
 		} else if publicAPIs != nil && len(publicAPIs) > 0 {
 			resourceMap = mapAPIGWMethodsAndResourcesFromDefinedPublicAPIs(publicAPIs, cfg.RequestTimeout, authorizationType, i, nil)
 		} else {
 			enableAPIKeys := cfg.UsagePlans != nil && len(cfg.UsagePlans) > 0
-			resourceMap = mapApiGatewayMethodsAndResourcesFromPaths(paths, cfg.RequestTimeout, authorizationType, i, nil, enableAPIKeys)
+			resourceMap = mapApiGatewayMethodsAndResourcesFromPaths(hostRules[i].Paths, cfg.RequestTimeout, authorizationType, i, nil, enableAPIKeys, hostRules[i].Host)
 		}
 
 		for k, resource := range resourceMap {
@@ -927,16 +1049,27 @@ func BuildAPIGatewayTemplateFromIngressRule(cfg *TemplateConfig) *cfn.Template {
 			template.Resources[fmt.Sprintf("%s%d", DeploymentResourceName, i)] = deployment
 		}
 
-		if cfg.CustomDomainName != "" && cfg.CertificateArn != "" {
+		if cfg.CustomDomainName != "" && customDomainCertificateArn != "" {
 			if cfg.AWSAPIDefinitions != nil && len(cfg.AWSAPIDefinitions) > 0 {
-				basePathMapping := buildCustomDomainBasePathMapping(cfg.CustomDomainName, cfg.StageName, cfg.AWSAPIDefinitions[i].Context, i)
+				basePathMapping := buildCustomDomainBasePathMapping(cfg.CustomDomainName, CustomDomainResourceName, cfg.StageName, cfg.AWSAPIDefinitions[i].Context, i)
 				template.Resources[fmt.Sprintf("%s%d", CustomDomainBasePathMappingResourceName, i)] = basePathMapping
-			} else {
-				basePathMapping := buildCustomDomainBasePathMapping(cfg.CustomDomainName, cfg.StageName, cfg.CustomDomainBasePath, i)
+			} else if i == customDomainAPIIndex(hostRules, cfg.CustomDomainName) {
+				basePathMapping := buildCustomDomainBasePathMapping(cfg.CustomDomainName, CustomDomainResourceName, cfg.StageName, cfg.CustomDomainBasePath, i)
 				template.Resources[fmt.Sprintf("%s%d", CustomDomainBasePathMappingResourceName, i)] = basePathMapping
 			}
 		}
 
+		// Every other host covered by a spec.tls entry gets a custom domain of its own, the certificate-arn annotation
+		// is for custom-domain-name only
+		if hostRouting && hostRules[i].Host != "" && hostRules[i].Host != cfg.CustomDomainName {
+			hostCertificateArn := TLSHostCertificateArn(cfg.TLSCertificateArns, hostRules[i].Host)
+			if hostCertificateArn != "" {
+				domainResourceName := fmt.Sprintf("%s%d", HostCustomDomainResourceName, i)
+				template.Resources[domainResourceName] = buildCustomDomain(hostRules[i].Host, hostCertificateArn, cfg.APIEndpointType, cfg.TLSPolicy)
+				template.Resources[fmt.Sprintf("%s%d", HostBasePathMappingResourceName, i)] = buildCustomDomainBasePathMapping(hostRules[i].Host, domainResourceName, cfg.StageName, cfg.CustomDomainBasePath, i)
+			}
+		}
+
 		if cfg.WAFEnabled {
 			if cfg.WAFAssociation {
 				webACLAssociation := buildAWSWAFWebACLAssociation(cfg.StageName, i)
@@ -973,12 +1106,7 @@ func BuildAPIGatewayTemplateFromIngressRule(cfg *TemplateConfig) *cfn.Template {
 
 	}
 
-	var deploymentCount int
-	if cfg.AWSAPIDefinitions != nil && len(cfg.AWSAPIDefinitions) > 0 {
-		deploymentCount = len(cfg.AWSAPIDefinitions)
-	} else {
-		deploymentCount = 1
-	}
+	deploymentCount := apiSize
 	if useGlobalUsagePlans {
 		for j, usagePlan := range cfg.UsagePlans {
 			keyArr := buildAPIKey(usagePlan, globalUsagePlanIndex)
@@ -999,20 +1127,7 @@ func BuildAPIGatewayTemplateFromIngressRule(cfg *TemplateConfig) *cfn.Template {
 	vPCLink := buildAWSApiGatewayVpcLink([]string{LoadBalancerResourceName})
 	template.Resources[VPCLinkResourceName] = vPCLink
 
-	rulePaths, err := json.Marshal(cfg.Rule.IngressRuleValue.HTTP.Paths)
-	var rulePathsStr string
-	if err != nil {
-		rulePathsStr = ""
-	} else {
-		rulePathsStr = string(rulePaths)
-	}
-
-	template.Outputs = map[string]interface{}{
-		OutputKeyAPIEndpointType: Output{Value: cfg.APIEndpointType},
-		OutputKeyRequestTimeout:  Output{Value: fmt.Sprintf("%d", cfg.RequestTimeout)},
-		OutputKeyIngressRules:    Output{Value: rulePathsStr},
-	}
-
+	template.Outputs = map[string]interface{}{}
 	for i := 0; i < apiSize; i++ {
 		template.Outputs[fmt.Sprintf("%s%d", OutputKeyRestAPIID, i)] = Output{Value: cfn.Ref(fmt.Sprintf("%s%d", APIResourceName, i))}
 		template.Outputs[fmt.Sprintf("%s%d", OutputKeyAPIGatewayEndpoint, i)] = Output{Value: cfn.Join("", []string{"https://", cfn.Ref(fmt.Sprintf("%s%d", APIResourceName, i)), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", cfg.StageName})}
@@ -1020,62 +1135,31 @@ func BuildAPIGatewayTemplateFromIngressRule(cfg *TemplateConfig) *cfn.Template {
 		if cfg.WAFAssociation {
 			template.Outputs[fmt.Sprintf("%s%d", OutputKeyWAFAssociationCreated, i)] = Output{Value: cfn.Ref(fmt.Sprintf("%s%d", WAFAssociationResourceName, i))}
 		}
-	}
-
-	if cfg.UsagePlans != nil && len(cfg.UsagePlans) > 0 {
-		val, _ := json.Marshal(cfg.UsagePlans)
-		template.Outputs[OutputKeyUsagePlans] = Output{Value: string(val)}
-	}
-
-	if cfg.Arns != nil && len(cfg.Arns) > 0 {
-		template.Outputs[OutputKeyClientARNS] = Output{Value: strings.Join(cfg.Arns, ",")}
-	}
-
-	if cfg.MinimumCompressionSize > 0 {
-		template.Outputs[OutputMinimumCompressionSize] = Output{fmt.Sprintf("%d", cfg.MinimumCompressionSize)}
-	}
 
-	if cfg.AWSAPIDefinitions != nil && len(cfg.AWSAPIDefinitions) > 0 {
-		val, _ := json.Marshal(cfg.AWSAPIDefinitions)
-		template.Outputs[OutputKeyAWSAPIConfigs] = Output{Value: string(val)}
+		domainResourceName := fmt.Sprintf("%s%d", HostCustomDomainResourceName, i)
+		if _, ok := template.Resources[domainResourceName]; ok {
+			domainNameAttr, hostedZoneIDAttr := DistributionDomainNameResourceName, DistributionHostedZoneIdResourceName
+			if cfg.APIEndpointType == "REGIONAL" {
+				domainNameAttr, hostedZoneIDAttr = RegionalDomainNameResourceName, RegionalHostedZoneIdResourceName
+			}
+			template.Outputs[fmt.Sprintf("%s%d", OutputKeyHostCustomDomain, i)] = Output{Value: hostRules[i].Host}
+			template.Outputs[fmt.Sprintf("%s%d", OutputKeyHostCustomDomainHostName, i)] = Output{Value: cfn.GetAtt(domainResourceName, domainNameAttr)}
+			template.Outputs[fmt.Sprintf("%s%d", OutputKeyHostCustomDomainHostedZoneID, i)] = Output{Value: cfn.GetAtt(domainResourceName, hostedZoneIDAttr)}
+		}
 	}
 
 	if cfg.WAFEnabled {
 		template.Outputs[OutputKeyWAFEnabled] = Output{Value: fmt.Sprintf("%t", cfg.WAFEnabled)}
-		template.Outputs[OutputKeyWAFRules] = Output{Value: cfg.WAFRulesJSON}
-		template.Outputs[OutputKeyWAFScope] = Output{Value: cfg.WAFScope}
 	}
 
 	if cfg.APIEndpointType == "REGIONAL" && cfg.CustomDomainName != "" {
-		template.Outputs[OutputKeyCertARN] = Output{Value: cfg.CertificateArn}
-		template.Outputs[OutputKeyCustomDomain] = Output{Value: cfg.CustomDomainName}
 		template.Outputs[OutputKeyCustomDomainHostName] = Output{Value: cfn.GetAtt(CustomDomainResourceName, RegionalDomainNameResourceName)}
 		template.Outputs[OutputKeyCustomDomainHostedZoneID] = Output{Value: cfn.GetAtt(CustomDomainResourceName, RegionalHostedZoneIdResourceName)}
-		template.Outputs[OutputKeyTLSPolicy] = Output{Value: cfg.TLSPolicy}
-		template.Outputs[OutputKeyCustomDomainBasePath] = Output{Value: cfg.CustomDomainBasePath}
 	}
 
 	if cfg.APIEndpointType == "EDGE" && cfg.CustomDomainName != "" {
-		template.Outputs[OutputKeyCertARN] = Output{Value: cfg.CertificateArn}
-		template.Outputs[OutputKeyCustomDomain] = Output{Value: cfg.CustomDomainName}
 		template.Outputs[OutputKeyCustomDomainHostName] = Output{Value: cfn.GetAtt(CustomDomainResourceName, DistributionDomainNameResourceName)}
 		template.Outputs[OutputKeyCustomDomainHostedZoneID] = Output{Value: cfn.GetAtt(CustomDomainResourceName, DistributionHostedZoneIdResourceName)}
-		template.Outputs[OutputKeyTLSPolicy] = Output{Value: cfg.TLSPolicy}
-		template.Outputs[OutputKeyCustomDomainBasePath] = Output{Value: cfg.CustomDomainBasePath}
-	}
-
-	if cfg.CachingEnabled && cfg.CachingSize != "" {
-		template.Outputs[OutputKeyCachingEnabled] = Output{Value: fmt.Sprintf("%t", cfg.CachingEnabled)}
-		template.Outputs[OutputKeyCacheClusterSize] = Output{Value: cfg.CachingSize}
-	}
-
-	if cfg.APIResources != nil && len(cfg.APIResources) > 0 {
-		val, _ := json.Marshal(cfg.APIResources)
-		template.Outputs[OutputKeyAPIResources] = Output{Value: string(val)}
-	}
-
-	if cfg.LoggingLevel != "" {
-		template.Outputs[OutputLoggingLevel] = Output{Value: cfg.LoggingLevel}
 	}
 
 	return template
@@ -1093,28 +1177,39 @@ func buildCustomDomainRoute53Record(domainName string, hostedZoneName string, dn
 	}
 }
 
+// HostCustomDomain is a custom domain created for an ingress rule host
+type HostCustomDomain struct {
+	DomainName   string `json:"domain_name"`
+	HostName     string `json:"host_name"`
+	HostedZoneID string `json:"hosted_zone_id"`
+}
+
 //Route53TemplateConfig is the structure of configuration used to provide data to build the cf template of route53
 type Route53TemplateConfig struct {
 	CustomDomainName         string
 	CustomDomainHostName     string
 	CustomDomainHostedZoneID string
 	HostedZoneName           string
+	HostCustomDomains        []HostCustomDomain
 }
 
 // BuildAPIGatewayRoute53Template generates the cloudformation template according to the config provided
 func BuildAPIGatewayRoute53Template(cfg *Route53TemplateConfig) *cfn.Template {
 	route53Template := cfn.NewTemplate()
 
-	if cfg.HostedZoneName != "" {
+	if cfg.HostedZoneName != "" && cfg.CustomDomainName != "" {
 		recordSet := buildCustomDomainRoute53Record(cfg.CustomDomainName, cfg.HostedZoneName, cfg.CustomDomainHostName, cfg.CustomDomainHostedZoneID)
 		route53Template.Resources[Route53RecordResourceName] = recordSet
 	}
 
-	route53Template.Outputs = map[string]interface{}{
-		OutputKeyCustomDomainHostName:     Output{Value: cfg.CustomDomainHostName},
-		OutputKeyCustomDomainHostedZoneID: Output{Value: cfg.CustomDomainHostedZoneID},
-		OutputKeyCustomDomain:             Output{Value: cfg.CustomDomainName},
-		OutputKeyHostedZone:               Output{Value: cfg.HostedZoneName},
+	// Only hosts inside the hosted zone get a record
+	zone := strings.TrimSuffix(cfg.HostedZoneName, ".")
+	for i, domain := range cfg.HostCustomDomains {
+		if zone == "" || !strings.HasSuffix(domain.DomainName, "."+zone) {
+			continue
+		}
+		recordSet := buildCustomDomainRoute53Record(domain.DomainName, cfg.HostedZoneName, domain.HostName, domain.HostedZoneID)
+		route53Template.Resources[fmt.Sprintf("%s%d", Route53RecordResourceName, i)] = recordSet
 	}
 
 	return route53Template
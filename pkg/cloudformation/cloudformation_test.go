@@ -1,19 +1,18 @@
 package cloudformation
 
 import (
-	"encoding/json"
 	"fmt"
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/network"
 	cfn "github.com/awslabs/goformation/v4/cloudformation"
 	"github.com/awslabs/goformation/v4/cloudformation/apigateway"
-	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	networkingv1 "k8s.io/api/networking/v1"
 )
 
 func getUsagePlans() []UsagePlan {
@@ -137,18 +136,6 @@ func getSecondUsagePlan() UsagePlan {
 	}
 }
 
-func getUsagePlanBytes() string {
-	usagePlan := getUsagePlans()
-	usagePlanBytes, _ := json.Marshal(usagePlan)
-	return string(usagePlanBytes)
-}
-
-func getSecondUsagePlanBytes() string {
-	usagePlan := getSecondUsagePlans()
-	usagePlanBytes, _ := json.Marshal(usagePlan)
-	return string(usagePlanBytes)
-}
-
 func getAPIKeyMappingBuild(i int, k int, index int) *apigateway.UsagePlanKey {
 	arr := buildUsagePlanAPIKeyMapping(getUsagePlan(), k, index)
 	for k, key := range arr {
@@ -279,12 +266,6 @@ func getAPIDefs() []AWSAPIDefinition {
 	}
 }
 
-func getAWSAPIDefBytes() string {
-	awsDefs := getAPIDefs()
-	awsDefsBytes, _ := json.Marshal(awsDefs)
-	return string(awsDefsBytes)
-}
-
 func getAuthDefCognito() AWSAPIAuthorizer {
 	return AWSAPIAuthorizer{
 		IdentitySource:               "foo",
@@ -408,12 +389,6 @@ func getAPIDefsWOUsagePlans() []AWSAPIDefinition {
 	}
 }
 
-func getAWSAPIDefWOUsagePlansBytes() string {
-	awsDefs := getAPIDefsWOUsagePlans()
-	awsDefsBytes, _ := json.Marshal(awsDefs)
-	return string(awsDefsBytes)
-}
-
 func getAPIResource() APIResource {
 	return APIResource{
 		Path: "/api/v1/foobar",
@@ -532,43 +507,6 @@ func getLambdaAPIResource() APIResource {
 	}
 }
 
-func getAPIResourcesBytes() string {
-	resourcesBytes, _ := json.Marshal(getAPIResources())
-	return string(resourcesBytes)
-}
-
-func getAPIWithLambdaResourcesBytes() string {
-	resourcesBytes, _ := json.Marshal(getAPIResourcesWithLambda())
-	return string(resourcesBytes)
-}
-
-func getIngressRulesJsonStr() string {
-	Rules := extensionsv1beta1.IngressRule{
-		IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-			HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-				Paths: []extensionsv1beta1.HTTPIngressPath{
-					{
-						Path: "/api/v1/foobar",
-						Backend: extensionsv1beta1.IngressBackend{
-							ServiceName: "foobar-service",
-							ServicePort: intstr.FromInt(8080),
-						},
-					},
-				},
-			},
-		},
-	}
-	rulePaths, err := json.Marshal(Rules.IngressRuleValue.HTTP.Paths)
-	var rulePathsStr string
-	if err != nil {
-		fmt.Println(err)
-		rulePathsStr = ""
-	} else {
-		rulePathsStr = string(rulePaths)
-	}
-	return rulePathsStr
-}
-
 func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 	tests := []struct {
 		name string
@@ -578,21 +516,23 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 		{
 			name: "generates template without custom domain",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -632,31 +572,29 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":          Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":   Output{Value: "EDGE"},
-					"RequestTimeout":      Output{Value: "10000"},
-					"IngressRules":        Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with content encoding",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -694,34 +632,31 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"VPCLink":                   buildAWSApiGatewayVpcLink([]string{"LoadBalancer"}),
 				},
 				Outputs: map[string]interface{}{
-					"RestAPIID0":             Output{Value: cfn.Ref("RestAPI0")},
-					"APIGatewayEndpoint0":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":             Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":      Output{Value: "EDGE"},
-					"RequestTimeout":         Output{Value: "10000"},
-					"MinimumCompressionSize": Output{Value: "1000000000"},
-					"IngressRules":           Output{Value: getIngressRulesJsonStr()},
+					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
+					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
 				},
 			},
 		},
 		{
 			name: "generates template with content encoding api keys",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -764,34 +699,31 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"APIKey010":                 getAPIKeyBuild(1),
 				},
 				Outputs: map[string]interface{}{
-					"RestAPIID0":             Output{Value: cfn.Ref("RestAPI0")},
-					"APIGatewayEndpoint0":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGWEndpointType":      Output{Value: "EDGE"},
-					"RequestTimeout":         Output{Value: "10000"},
-					"MinimumCompressionSize": Output{Value: "1000000000"},
-					"UsagePlansData":         Output{Value: getUsagePlanBytes()},
-					"IngressRules":           Output{Value: getIngressRulesJsonStr()},
+					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
+					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
 				},
 			},
 		},
 		{
 			name: "generates template with usage plan",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -836,31 +768,29 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGWEndpointType":   Output{Value: "EDGE"},
-					"RequestTimeout":      Output{Value: "10000"},
-					"UsagePlansData":      Output{Value: getUsagePlanBytes()},
-					"IngressRules":        Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with waf",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -905,35 +835,31 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":          Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":   Output{Value: "EDGE"},
 					"WAFEnabled":          Output{Value: "true"},
-					"WAFRules":            Output{Value: "[]"},
-					"WAFScope":            Output{Value: "REGIONAL"},
 					"WAFAssociation0":     Output{Value: cfn.Ref("WAFAssociation0")},
-					"RequestTimeout":      Output{Value: "10000"},
-					"IngressRules":        Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with waf regional api",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -979,35 +905,31 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":          Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":   Output{Value: "REGIONAL"},
 					"WAFEnabled":          Output{Value: "true"},
-					"WAFRules":            Output{Value: "[]"},
-					"WAFScope":            Output{Value: "REGIONAL"},
 					"WAFAssociation0":     Output{Value: cfn.Ref("WAFAssociation0")},
-					"RequestTimeout":      Output{Value: "10000"},
-					"IngressRules":        Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with waf null rules",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1051,35 +973,31 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":          Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":   Output{Value: "EDGE"},
 					"WAFEnabled":          Output{Value: "true"},
-					"WAFRules":            Output{Value: ""},
-					"WAFScope":            Output{Value: "REGIONAL"},
 					"WAFAssociation0":     Output{Value: cfn.Ref("WAFAssociation0")},
-					"RequestTimeout":      Output{Value: "10000"},
-					"IngressRules":        Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with waf error rules",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1124,35 +1042,31 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":          Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":   Output{Value: "EDGE"},
 					"WAFEnabled":          Output{Value: "true"},
-					"WAFRules":            Output{Value: "wrongjson"},
-					"WAFScope":            Output{Value: "REGIONAL"},
 					"WAFAssociation0":     Output{Value: cfn.Ref("WAFAssociation0")},
-					"RequestTimeout":      Output{Value: "10000"},
-					"IngressRules":        Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with custom domain",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1191,42 +1105,36 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"LoadBalancer":                 buildAWSElasticLoadBalancingV2LoadBalancer([]string{"sn-foo"}),
 					"VPCLink":                      buildAWSApiGatewayVpcLink([]string{"LoadBalancer"}),
 					"CustomDomain":                 buildCustomDomain("example.com", "arn::foobar", "EDGE", "TLS_1_2"),
-					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", "baz", "", 0),
+					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", CustomDomainResourceName, "baz", "", 0),
 				},
 				Outputs: map[string]interface{}{
 					"RestAPIID0":               Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0":      Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":               Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":        Output{Value: "EDGE"},
-					"SSLCertArn":               Output{Value: "arn::foobar"},
-					"CustomDomainName":         Output{Value: "example.com"},
 					"CustomDomainHostname":     Output{Value: cfn.GetAtt("CustomDomain", "DistributionDomainName")},
 					"CustomDomainHostedZoneID": Output{Value: cfn.GetAtt("CustomDomain", "DistributionHostedZoneId")},
-					"RequestTimeout":           Output{Value: "10000"},
-					"TLSPolicy":                Output{Value: "TLS_1_2"},
-					"CustomDomainBasePath":     Output{Value: ""},
-					"IngressRules":             Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with custom domain with base path",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1266,42 +1174,36 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"LoadBalancer":                 buildAWSElasticLoadBalancingV2LoadBalancer([]string{"sn-foo"}),
 					"VPCLink":                      buildAWSApiGatewayVpcLink([]string{"LoadBalancer"}),
 					"CustomDomain":                 buildCustomDomain("example.com", "arn::foobar", "EDGE", "TLS_1_2"),
-					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", "baz", "foo", 0),
+					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", CustomDomainResourceName, "baz", "foo", 0),
 				},
 				Outputs: map[string]interface{}{
 					"RestAPIID0":               Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0":      Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":               Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":        Output{Value: "EDGE"},
-					"SSLCertArn":               Output{Value: "arn::foobar"},
-					"CustomDomainName":         Output{Value: "example.com"},
 					"CustomDomainHostname":     Output{Value: cfn.GetAtt("CustomDomain", "DistributionDomainName")},
 					"CustomDomainHostedZoneID": Output{Value: cfn.GetAtt("CustomDomain", "DistributionHostedZoneId")},
-					"RequestTimeout":           Output{Value: "10000"},
-					"TLSPolicy":                Output{Value: "TLS_1_2"},
-					"CustomDomainBasePath":     Output{Value: "foo"},
-					"IngressRules":             Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with custom domain regional api",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1341,42 +1243,36 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"LoadBalancer":                 buildAWSElasticLoadBalancingV2LoadBalancer([]string{"sn-foo"}),
 					"VPCLink":                      buildAWSApiGatewayVpcLink([]string{"LoadBalancer"}),
 					"CustomDomain":                 buildCustomDomain("example.com", "arn::foobar", "REGIONAL", "TLS_1_2"),
-					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", "baz", "", 0),
+					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", CustomDomainResourceName, "baz", "", 0),
 				},
 				Outputs: map[string]interface{}{
 					"RestAPIID0":               Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0":      Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":               Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":        Output{Value: "REGIONAL"},
-					"SSLCertArn":               Output{Value: "arn::foobar"},
-					"CustomDomainName":         Output{Value: "example.com"},
 					"CustomDomainHostname":     Output{Value: cfn.GetAtt("CustomDomain", "RegionalDomainName")},
 					"CustomDomainHostedZoneID": Output{Value: cfn.GetAtt("CustomDomain", "RegionalHostedZoneId")},
-					"RequestTimeout":           Output{Value: "10000"},
-					"TLSPolicy":                Output{Value: "TLS_1_2"},
-					"CustomDomainBasePath":     Output{Value: ""},
-					"IngressRules":             Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with custom domain edge api with WAF",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1418,48 +1314,40 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"LoadBalancer":                 buildAWSElasticLoadBalancingV2LoadBalancer([]string{"sn-foo"}),
 					"VPCLink":                      buildAWSApiGatewayVpcLink([]string{"LoadBalancer"}),
 					"CustomDomain":                 buildCustomDomain("example.com", "arn::foobar", "EDGE", "TLS_1_2"),
-					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", "baz", "", 0),
+					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", CustomDomainResourceName, "baz", "", 0),
 					"WAFAcl":                       buildAWSWAFWebACL("REGIONAL", "[]"),
 					"WAFAssociation0":              buildAWSWAFWebACLAssociation("baz", 0),
 				},
 				Outputs: map[string]interface{}{
 					"RestAPIID0":               Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0":      Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":               Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":        Output{Value: "EDGE"},
-					"SSLCertArn":               Output{Value: "arn::foobar"},
-					"CustomDomainName":         Output{Value: "example.com"},
 					"CustomDomainHostname":     Output{Value: cfn.GetAtt("CustomDomain", "DistributionDomainName")},
 					"CustomDomainHostedZoneID": Output{Value: cfn.GetAtt("CustomDomain", "DistributionHostedZoneId")},
 					"WAFEnabled":               Output{Value: "true"},
-					"WAFRules":                 Output{Value: "[]"},
-					"WAFScope":                 Output{Value: "REGIONAL"},
 					"WAFAssociation0":          Output{Value: cfn.Ref("WAFAssociation0")},
-					"RequestTimeout":           Output{Value: "10000"},
-					"TLSPolicy":                Output{Value: "TLS_1_2"},
-					"CustomDomainBasePath":     Output{Value: ""},
-					"IngressRules":             Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with custom domain edge api with WAF without association",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1501,47 +1389,39 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"LoadBalancer":                 buildAWSElasticLoadBalancingV2LoadBalancer([]string{"sn-foo"}),
 					"VPCLink":                      buildAWSApiGatewayVpcLink([]string{"LoadBalancer"}),
 					"CustomDomain":                 buildCustomDomain("example.com", "arn::foobar", "EDGE", "TLS_1_2"),
-					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", "baz", "", 0),
+					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", CustomDomainResourceName, "baz", "", 0),
 					"WAFAcl":                       buildAWSWAFWebACL("REGIONAL", "[]"),
 				},
 				Outputs: map[string]interface{}{
 					"RestAPIID0":               Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0":      Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":               Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":        Output{Value: "EDGE"},
-					"SSLCertArn":               Output{Value: "arn::foobar"},
-					"CustomDomainName":         Output{Value: "example.com"},
 					"CustomDomainHostname":     Output{Value: cfn.GetAtt("CustomDomain", "DistributionDomainName")},
 					"CustomDomainHostedZoneID": Output{Value: cfn.GetAtt("CustomDomain", "DistributionHostedZoneId")},
 					"WAFEnabled":               Output{Value: "true"},
-					"WAFRules":                 Output{Value: "[]"},
-					"WAFScope":                 Output{Value: "REGIONAL"},
 					"WAFAssociation0":          Output{Value: cfn.Ref("WAFAssociation0")},
-					"RequestTimeout":           Output{Value: "10000"},
-					"TLSPolicy":                Output{Value: "TLS_1_2"},
-					"CustomDomainBasePath":     Output{Value: ""},
-					"IngressRules":             Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with custom domain regional api with WAF",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1584,48 +1464,40 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"LoadBalancer":                 buildAWSElasticLoadBalancingV2LoadBalancer([]string{"sn-foo"}),
 					"VPCLink":                      buildAWSApiGatewayVpcLink([]string{"LoadBalancer"}),
 					"CustomDomain":                 buildCustomDomain("example.com", "arn::foobar", "REGIONAL", "TLS_1_2"),
-					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", "baz", "", 0),
+					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", CustomDomainResourceName, "baz", "", 0),
 					"WAFAcl":                       buildAWSWAFWebACL("REGIONAL", "[]"),
 					"WAFAssociation0":              buildAWSWAFWebACLAssociation("baz", 0),
 				},
 				Outputs: map[string]interface{}{
 					"RestAPIID0":               Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0":      Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":               Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":        Output{Value: "REGIONAL"},
-					"SSLCertArn":               Output{Value: "arn::foobar"},
-					"CustomDomainName":         Output{Value: "example.com"},
 					"CustomDomainHostname":     Output{Value: cfn.GetAtt("CustomDomain", "RegionalDomainName")},
 					"CustomDomainHostedZoneID": Output{Value: cfn.GetAtt("CustomDomain", "RegionalHostedZoneId")},
 					"WAFEnabled":               Output{Value: "true"},
-					"WAFRules":                 Output{Value: "[]"},
-					"WAFScope":                 Output{Value: "REGIONAL"},
 					"WAFAssociation0":          Output{Value: cfn.Ref("WAFAssociation0")},
-					"RequestTimeout":           Output{Value: "10000"},
-					"TLSPolicy":                Output{Value: "TLS_1_2"},
-					"CustomDomainBasePath":     Output{Value: ""},
-					"IngressRules":             Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with defined public apis",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1665,32 +1537,29 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":          Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":   Output{Value: "EDGE"},
-					"RequestTimeout":      Output{Value: "10000"},
-					"APIResources":        Output{Value: getAPIWithLambdaResourcesBytes()},
-					"IngressRules":        Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with defined public apis with cache",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1729,34 +1598,29 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":          Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":   Output{Value: "EDGE"},
-					"RequestTimeout":      Output{Value: "10000"},
-					"CachingEnabled":      Output{Value: "true"},
-					"CachingSize":         Output{Value: "0.5"},
-					"APIResources":        Output{Value: getAPIResourcesBytes()},
-					"IngressRules":        Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template with defined public apis with cache size only",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1795,34 +1659,29 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
 					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"ClientARNS":          Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":   Output{Value: "EDGE"},
-					"RequestTimeout":      Output{Value: "10000"},
-					"CachingEnabled":      Output{Value: "true"},
-					"CachingSize":         Output{Value: "0.5"},
-					"APIResources":        Output{Value: getAPIResourcesBytes()},
-					"IngressRules":        Output{Value: getIngressRulesJsonStr()},
 				},
 			},
 		},
 		{
 			name: "generates template API Defs with Usage plans and auth enabled",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -1892,47 +1751,39 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"RestAPIAuthorizer10":          buildAuthorizer(getAuthDef(), 1),
 					"RestAPIAuthorizer11":          buildAuthorizer(getAuthDefCognito(), 1),
 					"CustomDomain":                 buildCustomDomain("example.com", "arn::foobar", "EDGE", "TLS_1_2"),
-					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", "baf", "api0", 0),
-					"CustomDomainBasePathMapping1": buildCustomDomainBasePathMapping("example.com", "baf", "api1", 1),
+					"CustomDomainBasePathMapping0": buildCustomDomainBasePathMapping("example.com", CustomDomainResourceName, "baf", "api0", 0),
+					"CustomDomainBasePathMapping1": buildCustomDomainBasePathMapping("example.com", CustomDomainResourceName, "baf", "api1", 1),
 				},
 				Outputs: map[string]interface{}{
 					"RestAPIID0":               Output{Value: cfn.Ref("RestAPI0")},
 					"RestAPIID1":               Output{Value: cfn.Ref("RestAPI1")},
 					"APIGatewayEndpoint0":      Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baf"})},
 					"APIGatewayEndpoint1":      Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI1"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baf"})},
-					"ClientARNS":               Output{Value: strings.Join([]string{"arn::foo"}, ",")},
-					"APIGWEndpointType":        Output{Value: "EDGE"},
-					"RequestTimeout":           Output{Value: "10000"},
-					"AWSAPIConfigs":            Output{Value: getAWSAPIDefBytes()},
-					"SSLCertArn":               Output{Value: "arn::foobar"},
-					"CustomDomainName":         Output{Value: "example.com"},
 					"CustomDomainHostname":     Output{Value: cfn.GetAtt("CustomDomain", "DistributionDomainName")},
 					"CustomDomainHostedZoneID": Output{Value: cfn.GetAtt("CustomDomain", "DistributionHostedZoneId")},
-					"TLSPolicy":                Output{Value: "TLS_1_2"},
-					"CustomDomainBasePath":     Output{Value: ""},
-					"IngressRules":             Output{Value: getIngressRulesJsonStr()},
-					"UsagePlansData":           Output{Value: getSecondUsagePlanBytes()},
 				},
 			},
 		},
 		{
 			name: "generates template API Defs without Usage plans and with auth enabled",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -2018,41 +1869,37 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"RestAPIAuthorizer22":       buildAuthorizer(getAuthDefRequest(), 2),
 				},
 				Outputs: map[string]interface{}{
-					"RestAPIID0":             Output{Value: cfn.Ref("RestAPI0")},
-					"RestAPIID1":             Output{Value: cfn.Ref("RestAPI1")},
-					"RestAPIID2":             Output{Value: cfn.Ref("RestAPI2")},
-					"RestAPIID3":             Output{Value: cfn.Ref("RestAPI3")},
-					"APIGatewayEndpoint0":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGatewayEndpoint1":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI1"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGatewayEndpoint2":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI2"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGatewayEndpoint3":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI3"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGWEndpointType":      Output{Value: "EDGE"},
-					"RequestTimeout":         Output{Value: "10000"},
-					"MinimumCompressionSize": Output{Value: "1000000000"},
-					"UsagePlansData":         Output{Value: getUsagePlanBytes()},
-					"AWSAPIConfigs":          Output{Value: getAWSAPIDefWOUsagePlansBytes()},
-					"IngressRules":           Output{Value: getIngressRulesJsonStr()},
+					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
+					"RestAPIID1":          Output{Value: cfn.Ref("RestAPI1")},
+					"RestAPIID2":          Output{Value: cfn.Ref("RestAPI2")},
+					"RestAPIID3":          Output{Value: cfn.Ref("RestAPI3")},
+					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
+					"APIGatewayEndpoint1": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI1"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
+					"APIGatewayEndpoint2": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI2"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
+					"APIGatewayEndpoint3": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI3"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
 				},
 			},
 		},
 		{
 			name: "generates template API Defs without Usage plans and with auth enabled no compression",
 			args: &TemplateConfig{
-				Rule: extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
+				Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
 								{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foobar-service",
-										ServicePort: intstr.FromInt(8080),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foobar-service",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
 									},
 								},
 							},
 						},
 					},
-				},
+				}},
 				Network: &network.Network{
 					Vpc: &ec2.Vpc{
 						VpcId:     aws.String("foo"),
@@ -2137,20 +1984,14 @@ func TestBuildApiGatewayTemplateFromIngressRule(t *testing.T) {
 					"RestAPIAuthorizer22":       buildAuthorizer(getAuthDefRequest(), 2),
 				},
 				Outputs: map[string]interface{}{
-					"RestAPIID0":             Output{Value: cfn.Ref("RestAPI0")},
-					"RestAPIID1":             Output{Value: cfn.Ref("RestAPI1")},
-					"RestAPIID2":             Output{Value: cfn.Ref("RestAPI2")},
-					"RestAPIID3":             Output{Value: cfn.Ref("RestAPI3")},
-					"APIGatewayEndpoint0":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGatewayEndpoint1":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI1"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGatewayEndpoint2":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI2"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGatewayEndpoint3":    Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI3"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
-					"APIGWEndpointType":      Output{Value: "EDGE"},
-					"RequestTimeout":         Output{Value: "10000"},
-					"MinimumCompressionSize": Output{Value: "1000000000"},
-					"UsagePlansData":         Output{Value: getUsagePlanBytes()},
-					"AWSAPIConfigs":          Output{Value: getAWSAPIDefWOUsagePlansBytes()},
-					"IngressRules":           Output{Value: getIngressRulesJsonStr()},
+					"RestAPIID0":          Output{Value: cfn.Ref("RestAPI0")},
+					"RestAPIID1":          Output{Value: cfn.Ref("RestAPI1")},
+					"RestAPIID2":          Output{Value: cfn.Ref("RestAPI2")},
+					"RestAPIID3":          Output{Value: cfn.Ref("RestAPI3")},
+					"APIGatewayEndpoint0": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI0"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
+					"APIGatewayEndpoint1": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI1"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
+					"APIGatewayEndpoint2": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI2"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
+					"APIGatewayEndpoint3": Output{Value: cfn.Join("", []string{"https://", cfn.Ref("RestAPI3"), ".execute-api.", cfn.Ref("AWS::Region"), ".amazonaws.com/", "baz"})},
 				},
 			},
 		},
@@ -2202,8 +2043,6 @@ func TestBuildApiGatewayTemplateForRoute53(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"CustomDomainHostname":     Output{Value: "d-example.aws.com"},
 					"CustomDomainHostedZoneID": Output{Value: "123234"},
-					"CustomDomainName":         Output{Value: "example.com"},
-					"HostedZone":               Output{Value: "example.com"},
 				},
 			},
 		},
@@ -2223,8 +2062,6 @@ func TestBuildApiGatewayTemplateForRoute53(t *testing.T) {
 				Outputs: map[string]interface{}{
 					"CustomDomainHostname":     Output{Value: "d-example.aws.com"},
 					"CustomDomainHostedZoneID": Output{Value: "123234"},
-					"CustomDomainName":         Output{Value: "example.com"},
-					"HostedZone":               Output{Value: "example.com"},
 				},
 			},
 		},
@@ -2246,3 +2083,271 @@ func TestBuildApiGatewayTemplateForRoute53(t *testing.T) {
 		})
 	}
 }
+
+func TestMapApiGatewayMethodsAndResourcesFromPaths(t *testing.T) {
+	exact := networkingv1.PathTypeExact
+	prefix := networkingv1.PathTypePrefix
+	backend := networkingv1.IngressBackend{
+		Service: &networkingv1.IngressServiceBackend{
+			Name: "foobar-service",
+			Port: networkingv1.ServiceBackendPort{Name: "http"},
+		},
+	}
+	tests := []struct {
+		name  string
+		paths []networkingv1.HTTPIngressPath
+		want  map[string]cfn.Resource
+	}{
+		{
+			name:  "exact path only gets a method on the last path part",
+			paths: []networkingv1.HTTPIngressPath{{Path: "/api/v1", PathType: &exact, Backend: backend}},
+			want: map[string]cfn.Resource{
+				"Resourceapi0":   buildAWSApiGatewayResource(cfn.GetAtt("RestAPI0", "RootResourceId"), "api", 0),
+				"Resourceapiv10": buildAWSApiGatewayResource(cfn.Ref("Resourceapi0"), "v1", 0),
+				"Methodapiv10":   buildAWSApiGatewayMethod("Resourceapiv10", "/api/v1", 10000, "NONE", "ANY", APIResource{}, 0, nil, 0, false, nil),
+			},
+		},
+		{
+			name:  "prefix path with trailing slash gets a greedy proxy resource",
+			paths: []networkingv1.HTTPIngressPath{{Path: "/api/", PathType: &prefix, Backend: backend}},
+			want: map[string]cfn.Resource{
+				"Resourceapi0":      buildAWSApiGatewayResource(cfn.GetAtt("RestAPI0", "RootResourceId"), "api", 0),
+				"Resourceapiproxy0": buildAWSApiGatewayResource(cfn.Ref("Resourceapi0"), "{proxy+}", 0),
+				"Methodapi0":        buildAWSApiGatewayMethod("Resourceapi0", "/api", 10000, "NONE", "ANY", APIResource{}, 0, nil, 0, false, nil),
+				"Methodapiproxy0":   buildAWSApiGatewayMethod("Resourceapiproxy0", "/api/{proxy}", 10000, "NONE", "ANY", APIResource{}, 0, nil, 0, false, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mapApiGatewayMethodsAndResourcesFromPaths(tt.paths, 10000, "NONE", 0, nil, false, "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mapApiGatewayMethodsAndResourcesFromPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildApiGatewayTemplateFromIngressRule_hosts(t *testing.T) {
+	newRule := func(host string, paths ...string) networkingv1.IngressRule {
+		rule := networkingv1.IngressRule{Host: host, IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{}}}
+		for _, path := range paths {
+			rule.HTTP.Paths = append(rule.HTTP.Paths, networkingv1.HTTPIngressPath{
+				Path: path,
+				Backend: networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{Name: "foobar-service", Port: networkingv1.ServiceBackendPort{Number: 8080}},
+				},
+			})
+		}
+		return rule
+	}
+
+	rules := []networkingv1.IngressRule{
+		newRule("", "/api/v1/foobar"),
+		newRule("a.example.com", "/a"),
+		newRule("", "/api/v1/baz"),
+		newRule("b.example.com", "/b"),
+	}
+
+	hostRules := GroupRulesByHost(rules)
+	if len(hostRules) != 3 || hostRules[0].Host != "" || len(hostRules[0].Paths) != 2 || hostRules[1].Host != "a.example.com" || hostRules[2].Host != "b.example.com" {
+		t.Fatalf("GroupRulesByHost() = %+v", hostRules)
+	}
+
+	cfg := &TemplateConfig{
+		Rules: rules,
+		Network: &network.Network{
+			Vpc:              &ec2.Vpc{VpcId: aws.String("foo"), CidrBlock: aws.String("10.0.0.0/24")},
+			InstanceIDs:      []string{"i-foo"},
+			SubnetIDs:        []string{"sn-foo"},
+			SecurityGroupIDs: []string{"sg-foo"},
+		},
+		NodePort:           30123,
+		StageName:          "baz",
+		CustomDomainName:   "a.example.com",
+		CertificateArn:     "arn::cert",
+		TLSCertificateArns: map[string]string{"b.example.com": "arn::b"},
+		APIEndpointType:    "REGIONAL",
+	}
+	template := BuildAPIGatewayTemplateFromIngressRule(cfg)
+
+	for _, name := range []string{"RestAPI0", "RestAPI1", "RestAPI2", "Deployment2", "CustomDomain", "CustomDomainBasePathMapping1", "HostCustomDomain2", "HostCustomDomainBasePathMapping2", "Methodapiv1baz0", "Methodb2"} {
+		if _, ok := template.Resources[name]; !ok {
+			t.Errorf("BuildAPIGatewayTemplateFromIngressRule() missing resource %s", name)
+		}
+	}
+	for _, name := range []string{"CustomDomainBasePathMapping0", "HostCustomDomain1", "Methoda0", "Methodb1"} {
+		if _, ok := template.Resources[name]; ok {
+			t.Errorf("BuildAPIGatewayTemplateFromIngressRule() unexpected resource %s", name)
+		}
+	}
+
+	method := template.Resources["Methodb2"].(*apigateway.Method)
+	if got := method.Integration.RequestParameters["integration.request.header.Host"]; got != "'b.example.com'" {
+		t.Errorf("host header = %q, want 'b.example.com'", got)
+	}
+	if _, ok := template.Resources["Methodapiv1baz0"].(*apigateway.Method).Integration.RequestParameters["integration.request.header.Host"]; ok {
+		t.Errorf("host-less rule must not set the host header")
+	}
+	if got := template.Outputs[fmt.Sprintf("%s%d", OutputKeyHostCustomDomain, 2)]; !reflect.DeepEqual(got, Output{Value: "b.example.com"}) {
+		t.Errorf("host custom domain output = %v", got)
+	}
+	if got := template.Resources["HostCustomDomain2"].(*apigateway.DomainName).RegionalCertificateArn; got != "arn::b" {
+		t.Errorf("host custom domain certificate = %q, want the TLS certificate of the host", got)
+	}
+
+	// The certificate-arn annotation doesn't make other hosts custom domains
+	cfg.TLSCertificateArns = nil
+	template = BuildAPIGatewayTemplateFromIngressRule(cfg)
+	if _, ok := template.Resources["HostCustomDomain2"]; ok {
+		t.Errorf("BuildAPIGatewayTemplateFromIngressRule() created a custom domain for a host without TLS entry")
+	}
+	if _, ok := template.Outputs[fmt.Sprintf("%s%d", OutputKeyHostCustomDomain, 2)]; ok {
+		t.Errorf("BuildAPIGatewayTemplateFromIngressRule() published a host without TLS entry")
+	}
+}
+
+func TestBuildAPIGatewayRoute53Template_hosts(t *testing.T) {
+	template := BuildAPIGatewayRoute53Template(&Route53TemplateConfig{
+		CustomDomainName:         "example.com",
+		CustomDomainHostName:     "d-foo.execute-api.amazonaws.com",
+		CustomDomainHostedZoneID: "Z1",
+		HostedZoneName:           "example.com.",
+		HostCustomDomains: []HostCustomDomain{
+			{DomainName: "a.example.com", HostName: "d-a.execute-api.amazonaws.com", HostedZoneID: "Z1"},
+			{DomainName: "a.example.org", HostName: "d-b.execute-api.amazonaws.com", HostedZoneID: "Z1"},
+		},
+	})
+
+	if _, ok := template.Resources[Route53RecordResourceName]; !ok {
+		t.Errorf("BuildAPIGatewayRoute53Template() missing custom domain record")
+	}
+	if _, ok := template.Resources[Route53RecordResourceName+"0"]; !ok {
+		t.Errorf("BuildAPIGatewayRoute53Template() missing record of host in the hosted zone")
+	}
+	if _, ok := template.Resources[Route53RecordResourceName+"1"]; ok {
+		t.Errorf("BuildAPIGatewayRoute53Template() created record of host outside the hosted zone")
+	}
+}
+
+func TestResolveCertificateArn(t *testing.T) {
+	tlsCertificateArns := map[string]string{
+		"api.example.com": "arn::api",
+		"*.example.com":   "arn::wildcard",
+		"":                "arn::default",
+	}
+	tests := []struct {
+		name           string
+		certificateArn string
+		domainName     string
+		want           string
+	}{
+		{name: "annotation wins", certificateArn: "arn::annotation", domainName: "api.example.com", want: "arn::annotation"},
+		{name: "exact host", domainName: "api.example.com", want: "arn::api"},
+		{name: "wildcard host", domainName: "foo.example.com", want: "arn::wildcard"},
+		{name: "wildcard covers one label only", domainName: "foo.bar.example.com", want: "arn::default"},
+		{name: "default certificate", domainName: "example.org", want: "arn::default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveCertificateArn(tt.certificateArn, tlsCertificateArns, tt.domainName); got != tt.want {
+				t.Errorf("ResolveCertificateArn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSHostCertificateArn(t *testing.T) {
+	tlsCertificateArns := map[string]string{
+		"api.example.com": "arn::api",
+		"*.example.com":   "arn::wildcard",
+		"":                "arn::default",
+	}
+	tests := []struct {
+		domainName string
+		want       string
+	}{
+		{domainName: "api.example.com", want: "arn::api"},
+		{domainName: "foo.example.com", want: "arn::wildcard"},
+		{domainName: "foo.bar.example.com", want: ""},
+		{domainName: "example.org", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.domainName, func(t *testing.T) {
+			if got := TLSHostCertificateArn(tlsCertificateArns, tt.domainName); got != tt.want {
+				t.Errorf("TLSHostCertificateArn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type mockStackEvents struct {
+	cloudformationiface.CloudFormationAPI
+	pages [][]*cloudformation.StackEvent
+}
+
+func (m *mockStackEvents) DescribeStackEventsPages(in *cloudformation.DescribeStackEventsInput, fn func(*cloudformation.DescribeStackEventsOutput, bool) bool) error {
+	for i, page := range m.pages {
+		if !fn(&cloudformation.DescribeStackEventsOutput{StackEvents: page}, i == len(m.pages)-1) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func stackEvent(logicalID, resourceType, status, reason string) *cloudformation.StackEvent {
+	return &cloudformation.StackEvent{
+		LogicalResourceId:    aws.String(logicalID),
+		ResourceType:         aws.String(resourceType),
+		ResourceStatus:       aws.String(status),
+		ResourceStatusReason: aws.String(reason),
+	}
+}
+
+func TestFirstFailedStackEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		pages  [][]*cloudformation.StackEvent
+		wantID string
+	}{
+		{
+			name: "earliest failure of the latest operation",
+			pages: [][]*cloudformation.StackEvent{
+				{
+					stackEvent("foobar", "AWS::CloudFormation::Stack", "ROLLBACK_COMPLETE", ""),
+					stackEvent("RestAPI0", "AWS::ApiGateway::RestApi", "CREATE_FAILED", "Resource creation cancelled"),
+				},
+				{
+					stackEvent("CustomDomain", "AWS::ApiGateway::DomainName", "CREATE_FAILED", "Invalid certificate ARN"),
+					stackEvent("foobar", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "User Initiated"),
+					stackEvent("Old", "AWS::ApiGateway::Stage", "UPDATE_FAILED", "from an earlier operation"),
+				},
+			},
+			wantID: "CustomDomain",
+		},
+		{
+			name: "no failed resource",
+			pages: [][]*cloudformation.StackEvent{
+				{
+					stackEvent("foobar", "AWS::CloudFormation::Stack", "CREATE_COMPLETE", ""),
+					stackEvent("foobar", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "User Initiated"),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FirstFailedStackEvent(&mockStackEvents{pages: tt.pages}, "foobar")
+			if err != nil {
+				t.Fatalf("FirstFailedStackEvent() error = %v", err)
+			}
+			var gotID string
+			if got != nil {
+				gotID = aws.StringValue(got.LogicalResourceId)
+			}
+			if gotID != tt.wantID {
+				t.Errorf("FirstFailedStackEvent() = %v, want %v", gotID, tt.wantID)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package cloudformation
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
@@ -109,6 +110,19 @@ func IsDoesNotExist(err error, stackName string) bool {
 	return false
 }
 
+// IsAlreadyExists tells if a stack couldn't be created because one of the same name exists, e.g. created by
+// another replica of the controller
+func IsAlreadyExists(err error) bool {
+	aErr, ok := err.(awserr.Error)
+	return ok && aErr.Code() == cloudformation.ErrCodeAlreadyExistsException
+}
+
+// IsChangeSetNotFound tells if the change set doesn't exist
+func IsChangeSetNotFound(err error) bool {
+	aErr, ok := err.(awserr.Error)
+	return ok && aErr.Code() == cloudformation.ErrCodeChangeSetNotFoundException
+}
+
 func DescribeStack(cfnSvc cloudformationiface.CloudFormationAPI, stackName string) (*cloudformation.Stack, error) {
 	out, err := cfnSvc.DescribeStacks(&cloudformation.DescribeStacksInput{
 		StackName: aws.String(stackName),
@@ -146,3 +160,48 @@ func StackOutputMap(stack *cloudformation.Stack) map[string]string {
 
 	return outputs
 }
+
+// FirstFailedStackEvent returns the event of the resource that failed first during the latest stack operation, or nil
+// if no resource failed. Events are listed newest first, so the search stops at the event starting the operation.
+func FirstFailedStackEvent(cfnSvc cloudformationiface.CloudFormationAPI, stackName string) (*cloudformation.StackEvent, error) {
+	var failed *cloudformation.StackEvent
+	err := cfnSvc.DescribeStackEventsPages(&cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(stackName),
+	}, func(out *cloudformation.DescribeStackEventsOutput, lastPage bool) bool {
+		for _, event := range out.StackEvents {
+			if strings.HasSuffix(aws.StringValue(event.ResourceStatus), "_FAILED") {
+				failed = event
+			}
+			if aws.StringValue(event.ResourceType) == "AWS::CloudFormation::Stack" && aws.StringValue(event.ResourceStatusReason) == "User Initiated" {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return failed, nil
+}
+
+// ResourcesInStatus returns the logical IDs of the stack resources in the given status, e.g. the UPDATE_FAILED
+// resources to skip when continuing a rollback or the DELETE_FAILED ones to retain when deleting again.
+func ResourcesInStatus(cfnSvc cloudformationiface.CloudFormationAPI, stackName string, status string) ([]string, error) {
+	var logicalIDs []string
+	err := cfnSvc.ListStackResourcesPages(&cloudformation.ListStackResourcesInput{
+		StackName: aws.String(stackName),
+	}, func(out *cloudformation.ListStackResourcesOutput, lastPage bool) bool {
+		for _, summary := range out.StackResourceSummaries {
+			if aws.StringValue(summary.ResourceStatus) == status {
+				logicalIDs = append(logicalIDs, aws.StringValue(summary.LogicalResourceId))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return logicalIDs, nil
+}
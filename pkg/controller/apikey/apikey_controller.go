@@ -0,0 +1,250 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/apigateway"
+	"github.com/aws/aws-sdk-go/service/apigateway/apigatewayiface"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/apis/apigateway/v1alpha1"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/awssession"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/finalizers"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/logging"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	FinalizerAPIKey = "apigateway.networking.amazonaws.com/apikey"
+	// TagAPIKey tags the keys with the namespace and name of their APIKey
+	TagAPIKey = "kubernetes.io/apikey"
+)
+
+// Add creates a new APIKey Controller and adds it to the Manager. The controller is skipped on clusters without the
+// APIKey CRD.
+func Add(mgr manager.Manager) error {
+	_, err := mgr.GetRESTMapper().RESTMapping(schema.GroupKind{Group: v1alpha1.SchemeGroupVersion.Group, Kind: "APIKey"}, v1alpha1.SchemeGroupVersion.Version)
+	if err != nil {
+		return nil
+	}
+
+	r, err := newReconciler(mgr)
+	if err != nil {
+		return err
+	}
+
+	return add(mgr, r)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) (*ReconcileAPIKey, error) {
+	logger := logging.New()
+	sess, err := awssession.New(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReconcileAPIKey{
+		Client:        mgr.GetClient(),
+		scheme:        mgr.GetScheme(),
+		log:           logger,
+		apigatewaySvc: apigateway.New(sess),
+	}, nil
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *ReconcileAPIKey) error {
+	c, err := controller.New("apikey-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(&source.Kind{Type: &v1alpha1.APIKey{}}, &handler.EnqueueRequestForObject{})
+}
+
+var _ reconcile.Reconciler = &ReconcileAPIKey{}
+
+// ReconcileAPIKey reconciles an APIKey object
+type ReconcileAPIKey struct {
+	client.Client
+	scheme        *runtime.Scheme
+	log           *zap.Logger
+	apigatewaySvc apigatewayiface.APIGatewayAPI
+}
+
+// Reconcile creates, updates and deletes the API Gateway key of an APIKey and reports its id in the status
+// +kubebuilder:rbac:groups=apigateway.networking.amazonaws.com,resources=apikeys,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=apigateway.networking.amazonaws.com,resources=apikeys/status,verbs=get;update;patch
+func (r *ReconcileAPIKey) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	instance := &v1alpha1.APIKey{}
+	if err := r.Get(ctx, request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !finalizers.HasFinalizer(instance, FinalizerAPIKey) {
+			return reconcile.Result{}, nil
+		}
+
+		if err := r.delete(instance); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		instance.SetFinalizers(finalizers.RemoveFinalizer(instance, FinalizerAPIKey))
+		return reconcile.Result{}, r.Update(ctx, instance)
+	}
+
+	if !finalizers.HasFinalizer(instance, FinalizerAPIKey) {
+		instance.SetFinalizers(finalizers.AddFinalizer(instance, FinalizerAPIKey))
+		if err := r.Update(ctx, instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	id, err := r.apply(instance)
+	if err != nil {
+		r.log.Error("unable to apply api key", zap.String("name", instance.Name), zap.Error(err))
+		return reconcile.Result{}, err
+	}
+
+	if instance.Status.ID == id && instance.Status.ObservedGeneration == instance.Generation {
+		return reconcile.Result{}, nil
+	}
+
+	instance.Status.ID = id
+	instance.Status.ObservedGeneration = instance.Generation
+	return reconcile.Result{}, r.Status().Update(ctx, instance)
+}
+
+func isNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == apigateway.ErrCodeNotFoundException
+}
+
+func isEnabled(instance *v1alpha1.APIKey) bool {
+	return instance.Spec.Enabled == nil || *instance.Spec.Enabled
+}
+
+// apply creates the key, or updates the one in the status. Keys removed outside of the controller are created again.
+// A key whose id never made it into the status is found by its tag and updated instead.
+func (r *ReconcileAPIKey) apply(instance *v1alpha1.APIKey) (string, error) {
+	if instance.Status.ID != "" {
+		key, err := r.apigatewaySvc.GetApiKey(&apigateway.GetApiKeyInput{ApiKey: aws.String(instance.Status.ID)})
+		if err == nil {
+			return instance.Status.ID, r.update(instance, key)
+		}
+		if !isNotFound(err) {
+			return "", err
+		}
+		r.log.Info("api key was removed from API Gateway, creating it again", zap.String("id", instance.Status.ID))
+	}
+
+	key, err := r.findAPIKey(instance)
+	if err != nil {
+		return "", err
+	}
+	if key != nil {
+		r.log.Info("found api key created earlier", zap.String("name", instance.Name), zap.String("id", aws.StringValue(key.Id)))
+		return aws.StringValue(key.Id), r.update(instance, key)
+	}
+
+	r.log.Info("creating api key", zap.String("name", instance.Name))
+	key, err = r.apigatewaySvc.CreateApiKey(&apigateway.CreateApiKeyInput{
+		Name:               aws.String(fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)),
+		Description:        aws.String(instance.Spec.Description),
+		Enabled:            aws.Bool(isEnabled(instance)),
+		CustomerId:         aws.String(instance.Spec.CustomerID),
+		GenerateDistinctId: aws.Bool(instance.Spec.GenerateDistinctID),
+		Tags: map[string]*string{
+			"managedBy": aws.String("amazon-apigateway-ingress-controller"),
+			TagAPIKey:   aws.String(fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(key.Id), nil
+}
+
+// findAPIKey returns the key tagged with the APIKey, or nil if there is none. Keys are named after their APIKey, which
+// narrows the search.
+func (r *ReconcileAPIKey) findAPIKey(instance *v1alpha1.APIKey) (*apigateway.ApiKey, error) {
+	name := fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)
+	var found *apigateway.ApiKey
+	err := r.apigatewaySvc.GetApiKeysPages(&apigateway.GetApiKeysInput{NameQuery: aws.String(name)}, func(out *apigateway.GetApiKeysOutput, _ bool) bool {
+		for _, key := range out.Items {
+			if aws.StringValue(key.Tags[TagAPIKey]) == name {
+				found = key
+				return false
+			}
+		}
+		return true
+	})
+
+	return found, err
+}
+
+func (r *ReconcileAPIKey) update(instance *v1alpha1.APIKey, key *apigateway.ApiKey) error {
+	var ops []*apigateway.PatchOperation
+	if aws.StringValue(key.Description) != instance.Spec.Description {
+		ops = append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpReplace), Path: aws.String("/description"), Value: aws.String(instance.Spec.Description)})
+	}
+	if aws.BoolValue(key.Enabled) != isEnabled(instance) {
+		ops = append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpReplace), Path: aws.String("/enabled"), Value: aws.String(strconv.FormatBool(isEnabled(instance)))})
+	}
+	if aws.StringValue(key.CustomerId) != instance.Spec.CustomerID {
+		ops = append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpReplace), Path: aws.String("/customerId"), Value: aws.String(instance.Spec.CustomerID)})
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	r.log.Info("updating api key", zap.String("name", instance.Name), zap.String("id", aws.StringValue(key.Id)))
+	_, err := r.apigatewaySvc.UpdateApiKey(&apigateway.UpdateApiKeyInput{ApiKey: key.Id, PatchOperations: ops})
+	return err
+}
+
+// delete removes the key from API Gateway, which also removes it from every usage plan
+func (r *ReconcileAPIKey) delete(instance *v1alpha1.APIKey) error {
+	if instance.Status.ID == "" {
+		return nil
+	}
+
+	r.log.Info("deleting api key", zap.String("name", instance.Name), zap.String("id", instance.Status.ID))
+	_, err := r.apigatewaySvc.DeleteApiKey(&apigateway.DeleteApiKeyInput{ApiKey: aws.String(instance.Status.ID)})
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	return nil
+}
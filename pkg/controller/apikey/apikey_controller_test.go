@@ -0,0 +1,212 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/apigateway"
+	"github.com/aws/aws-sdk-go/service/apigateway/apigatewayiface"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/apis/apigateway/v1alpha1"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type mockAPIGateway struct {
+	apigatewayiface.APIGatewayAPI
+	Keys    map[string]*apigateway.ApiKey
+	created int
+}
+
+func (m *mockAPIGateway) CreateApiKey(in *apigateway.CreateApiKeyInput) (*apigateway.ApiKey, error) {
+	key := &apigateway.ApiKey{
+		Id:          aws.String(fmt.Sprintf("key%d", m.created)),
+		Name:        in.Name,
+		Description: in.Description,
+		Enabled:     in.Enabled,
+		CustomerId:  in.CustomerId,
+		Tags:        in.Tags,
+	}
+	m.Keys[*key.Id] = key
+	m.created++
+	return key, nil
+}
+
+func (m *mockAPIGateway) GetApiKey(in *apigateway.GetApiKeyInput) (*apigateway.ApiKey, error) {
+	if key, ok := m.Keys[*in.ApiKey]; ok {
+		return key, nil
+	}
+	return nil, awserr.New(apigateway.ErrCodeNotFoundException, "Invalid API Key identifier specified", nil)
+}
+
+func (m *mockAPIGateway) GetApiKeysPages(in *apigateway.GetApiKeysInput, fn func(*apigateway.GetApiKeysOutput, bool) bool) error {
+	out := &apigateway.GetApiKeysOutput{}
+	for _, key := range m.Keys {
+		if strings.HasPrefix(aws.StringValue(key.Name), aws.StringValue(in.NameQuery)) {
+			out.Items = append(out.Items, key)
+		}
+	}
+	fn(out, true)
+	return nil
+}
+
+func (m *mockAPIGateway) UpdateApiKey(in *apigateway.UpdateApiKeyInput) (*apigateway.ApiKey, error) {
+	key := m.Keys[*in.ApiKey]
+	for _, op := range in.PatchOperations {
+		switch *op.Path {
+		case "/description":
+			key.Description = op.Value
+		case "/enabled":
+			key.Enabled = aws.Bool(*op.Value == "true")
+		case "/customerId":
+			key.CustomerId = op.Value
+		}
+	}
+	return key, nil
+}
+
+func (m *mockAPIGateway) DeleteApiKey(in *apigateway.DeleteApiKeyInput) (*apigateway.DeleteApiKeyOutput, error) {
+	delete(m.Keys, *in.ApiKey)
+	return &apigateway.DeleteApiKeyOutput{}, nil
+}
+
+func newScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = v1alpha1.AddToScheme(s)
+	return s
+}
+
+func TestReconcileAPIKey_Reconcile(t *testing.T) {
+	instance := &v1alpha1.APIKey{
+		ObjectMeta: metav1.ObjectMeta{Name: "customer", Namespace: "default"},
+		Spec:       v1alpha1.APIKeySpec{Description: "first customer", CustomerID: "c1"},
+	}
+	apigw := &mockAPIGateway{Keys: map[string]*apigateway.ApiKey{}}
+	c := fakeclient.NewFakeClientWithScheme(newScheme(), instance)
+	r := &ReconcileAPIKey{Client: c, log: logging.New(), apigatewaySvc: apigw}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "customer", Namespace: "default"}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileAPIKey.Reconcile() error = %v", err)
+	}
+
+	got := &v1alpha1.APIKey{}
+	if err := c.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unable to fetch api key: %v", err)
+	}
+	if got.Status.ID != "key0" {
+		t.Fatalf("status.id = %q, want key0", got.Status.ID)
+	}
+	if len(got.Finalizers) != 1 || got.Finalizers[0] != FinalizerAPIKey {
+		t.Errorf("finalizers = %v, want %s", got.Finalizers, FinalizerAPIKey)
+	}
+	if key := apigw.Keys["key0"]; aws.StringValue(key.Name) != "default/customer" || !aws.BoolValue(key.Enabled) {
+		t.Errorf("created key = %v", key)
+	}
+
+	// Spec changes are patched into the existing key
+	got.Spec.Enabled = aws.Bool(false)
+	got.Spec.Description = "disabled customer"
+	if err := c.Update(context.TODO(), got); err != nil {
+		t.Fatalf("unable to update api key: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileAPIKey.Reconcile() error = %v", err)
+	}
+	if key := apigw.Keys["key0"]; aws.BoolValue(key.Enabled) || aws.StringValue(key.Description) != "disabled customer" {
+		t.Errorf("updated key = %v", key)
+	}
+
+	// A key deleted outside of the controller is created again
+	delete(apigw.Keys, "key0")
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileAPIKey.Reconcile() error = %v", err)
+	}
+	if err := c.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unable to fetch api key: %v", err)
+	}
+	if _, ok := apigw.Keys[got.Status.ID]; !ok || got.Status.ID == "key0" {
+		t.Errorf("status.id = %q, want the id of a new key", got.Status.ID)
+	}
+}
+
+func TestReconcileAPIKey_adopt(t *testing.T) {
+	instance := &v1alpha1.APIKey{
+		ObjectMeta: metav1.ObjectMeta{Name: "customer", Namespace: "default"},
+		Spec:       v1alpha1.APIKeySpec{Description: "first customer"},
+	}
+	// The key of a pass that failed to record it in the status, and the key of another APIKey sharing the prefix
+	apigw := &mockAPIGateway{Keys: map[string]*apigateway.ApiKey{
+		"earlier": {Id: aws.String("earlier"), Name: aws.String("default/customer"), Tags: aws.StringMap(map[string]string{TagAPIKey: "default/customer"})},
+		"other":   {Id: aws.String("other"), Name: aws.String("default/customer-2"), Tags: aws.StringMap(map[string]string{TagAPIKey: "default/customer-2"})},
+	}}
+	c := fakeclient.NewFakeClientWithScheme(newScheme(), instance)
+	r := &ReconcileAPIKey{Client: c, log: logging.New(), apigatewaySvc: apigw}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "customer", Namespace: "default"}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileAPIKey.Reconcile() error = %v", err)
+	}
+
+	got := &v1alpha1.APIKey{}
+	if err := c.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unable to fetch api key: %v", err)
+	}
+	if got.Status.ID != "earlier" || apigw.created != 0 {
+		t.Errorf("status.id = %q after creating %d keys, want the earlier key", got.Status.ID, apigw.created)
+	}
+	if aws.StringValue(apigw.Keys["earlier"].Description) != "first customer" {
+		t.Errorf("earlier key = %v, want it updated", apigw.Keys["earlier"])
+	}
+}
+
+func TestReconcileAPIKey_delete(t *testing.T) {
+	now := metav1.Now()
+	instance := &v1alpha1.APIKey{
+		ObjectMeta: metav1.ObjectMeta{Name: "customer", Namespace: "default", DeletionTimestamp: &now, Finalizers: []string{FinalizerAPIKey}},
+		Status:     v1alpha1.APIKeyStatus{ID: "key0"},
+	}
+	apigw := &mockAPIGateway{Keys: map[string]*apigateway.ApiKey{"key0": {Id: aws.String("key0")}}}
+	c := fakeclient.NewFakeClientWithScheme(newScheme(), instance)
+	r := &ReconcileAPIKey{Client: c, log: logging.New(), apigatewaySvc: apigw}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "customer", Namespace: "default"}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileAPIKey.Reconcile() error = %v", err)
+	}
+	if len(apigw.Keys) != 0 {
+		t.Errorf("keys = %v, want the key deleted", apigw.Keys)
+	}
+
+	got := &v1alpha1.APIKey{}
+	if err := c.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unable to fetch api key: %v", err)
+	}
+	if len(got.Finalizers) != 0 {
+		t.Errorf("finalizers = %v, want none", got.Finalizers)
+	}
+}
@@ -0,0 +1,178 @@
+package ingress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"github.com/awslabs/goformation/v4/cloudformation/wafv2"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const defaultRequestTimeout = 29000
+
+var (
+	annotationsPath = field.NewPath("metadata", "annotations")
+
+	supportedEndpointTypes = []string{"EDGE", "REGIONAL"}
+	supportedTLSPolicies   = []string{"TLS_1_0", "TLS_1_2"}
+	supportedWAFScopes     = []string{"REGIONAL", "CLOUDFRONT"}
+)
+
+// parseAnnotations parses every typed annotation of the ingress and returns all errors, each pointing at the
+// annotation and, for JSON annotations, the field at fault. The get* helpers fall back to defaults instead, so
+// reconciling must not go ahead unless this returns no errors.
+func parseAnnotations(ingress *networkingv1.Ingress) field.ErrorList {
+	var errs field.ErrorList
+	collect := func(err *field.Error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	_, err := parseIntAnnotation(ingress, IngressAnnotationRequestTimeout, defaultRequestTimeout)
+	collect(err)
+	_, err = parseIntAnnotation(ingress, IngressAnnotationMinimumCompressionSize, 0)
+	collect(err)
+	_, err = parseIntAnnotation(ingress, IngressAnnotationNginxReplicas, DefaultNginxReplicas)
+	collect(err)
+	_, err = parseIntAnnotation(ingress, IngressAnnotationNginxServicePort, DefaultNginxServicePort)
+	collect(err)
+	_, err = parseBoolAnnotation(ingress, IngressAnnotationWAFEnabled)
+	collect(err)
+	_, err = parseBoolAnnotation(ingress, IngressAnnotationGWCacheEnabled)
+	collect(err)
+	_, err = parseBoolAnnotation(ingress, IngressAnnotationDryRun)
+	collect(err)
+	_, err = parseBoolAnnotation(ingress, IngressAnnotationRequireApproval)
+	collect(err)
+	_, err = parseEnumAnnotation(ingress, IngressAnnotationEndpointType, "EDGE", supportedEndpointTypes)
+	collect(err)
+	_, err = parseEnumAnnotation(ingress, IngressAnnotationTLSPolicy, "TLS_1_0", supportedTLSPolicies)
+	collect(err)
+	_, err = parseEnumAnnotation(ingress, IngressAnnotationWAFScope, "REGIONAL", supportedWAFScopes)
+	collect(err)
+	_, err = parseEnumAnnotation(ingress, IngressAnnotationStackRecoveryPolicy, StackRecoveryPolicyNone, supportedStackRecoveryPolicies)
+	collect(err)
+	_, err = parseIntAnnotation(ingress, IngressAnnotationStackRecoveryMaxRetries, DefaultStackRecoveryMaxRetries)
+	collect(err)
+	_, err = parseNodeSelector(ingress)
+	collect(err)
+	_, _, err = parseCanaryPercent(ingress)
+	collect(err)
+	_, err = parseEnumAnnotation(ingress, IngressAnnotationCanaryAction, "", supportedCanaryActions)
+	collect(err)
+	collect(parseJSONAnnotation(ingress, IngressAnnotationCanaryStageVariables, &map[string]string{}))
+	collect(parseJSONAnnotation(ingress, IngressAnnotationAWSAPIConfigs, &[]cfn.AWSAPIDefinition{}))
+	collect(parseJSONAnnotation(ingress, IngressAnnotationPublicResources, &[]cfn.APIResource{}))
+	collect(parseJSONAnnotation(ingress, IngressAnnotationAPIKeyBasedUsagePlans, &[]cfn.UsagePlan{}))
+	collect(parseJSONAnnotation(ingress, IngressAnnotationWAFRulesCFJson, &[]wafv2.WebACL_Rule{}))
+
+	return errs
+}
+
+// parseIntAnnotation returns def if the annotation isn't set
+func parseIntAnnotation(ingress *networkingv1.Ingress, key string, def int) (int, *field.Error) {
+	v := ingress.ObjectMeta.Annotations[key]
+	if v == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return def, field.Invalid(annotationsPath.Key(key), v, "must be an integer")
+	}
+
+	return i, nil
+}
+
+// parseBoolAnnotation returns false if the annotation isn't set
+func parseBoolAnnotation(ingress *networkingv1.Ingress, key string) (bool, *field.Error) {
+	v := ingress.ObjectMeta.Annotations[key]
+	if v == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, field.Invalid(annotationsPath.Key(key), v, "must be true or false")
+	}
+
+	return b, nil
+}
+
+// parseEnumAnnotation returns def if the annotation isn't set
+func parseEnumAnnotation(ingress *networkingv1.Ingress, key string, def string, values []string) (string, *field.Error) {
+	v := ingress.ObjectMeta.Annotations[key]
+	if v == "" {
+		return def, nil
+	}
+
+	if !contains(values, v) {
+		return def, field.NotSupported(annotationsPath.Key(key), v, values)
+	}
+
+	return v, nil
+}
+
+func parseNodeSelector(ingress *networkingv1.Ingress) (labels.Selector, *field.Error) {
+	v := ingress.ObjectMeta.Annotations[IngressAnnotationNodeSelector]
+	s, err := labels.Parse(v)
+	if err != nil {
+		return DefaultNodeSelector, field.Invalid(annotationsPath.Key(IngressAnnotationNodeSelector), v, err.Error())
+	}
+
+	return s, nil
+}
+
+// parseJSONAnnotation unmarshals the annotation into out, leaving it untouched if the annotation isn't set.
+// Unknown fields are rejected so that a misspelt key doesn't silently drop a setting.
+func parseJSONAnnotation(ingress *networkingv1.Ingress, key string, out interface{}) *field.Error {
+	v := ingress.ObjectMeta.Annotations[key]
+	if v == "" {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(v)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return jsonFieldError(annotationsPath.Key(key), err)
+	}
+	if decoder.More() {
+		return field.Invalid(annotationsPath.Key(key), v, "unexpected content after the JSON value")
+	}
+
+	return nil
+}
+
+// jsonFieldError turns a decoding error into an error on the JSON field at fault
+func jsonFieldError(path *field.Path, err error) *field.Error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			for _, name := range strings.Split(typeErr.Field, ".") {
+				if i, err := strconv.Atoi(name); err == nil {
+					path = path.Index(i)
+				} else {
+					path = path.Child(name)
+				}
+			}
+		}
+		return field.Invalid(path, typeErr.Value, fmt.Sprintf("must be of type %s", typeErr.Type))
+	case errors.As(err, &syntaxErr):
+		return field.Invalid(path, fmt.Sprintf("offset %d", syntaxErr.Offset), syntaxErr.Error())
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return field.Forbidden(path.Child(name), "unknown field")
+	}
+
+	return field.Invalid(path, nil, err.Error())
+}
@@ -0,0 +1,219 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/apis/apigateway/v1alpha1"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"go.uber.org/zap"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// IngressAnnotationConfig references an APIGatewayConfig in the namespace of the ingress
+const IngressAnnotationConfig = "apigateway.ingress.kubernetes.io/config"
+
+// servesAPIGatewayConfig checks whether the APIGatewayConfig CRD is installed
+func servesAPIGatewayConfig(mapper meta.RESTMapper) bool {
+	_, err := mapper.RESTMapping(schema.GroupKind{Group: v1alpha1.SchemeGroupVersion.Group, Kind: "APIGatewayConfig"}, v1alpha1.SchemeGroupVersion.Version)
+	return err == nil
+}
+
+// getAPIGatewayConfig returns the APIGatewayConfig referenced by the ingress, or nil if it doesn't reference one
+func (r *ReconcileIngress) getAPIGatewayConfig(instance *networkingv1.Ingress) (*v1alpha1.APIGatewayConfig, error) {
+	name := instance.Annotations[IngressAnnotationConfig]
+	if name == "" {
+		return nil, nil
+	}
+
+	config := &v1alpha1.APIGatewayConfig{}
+	if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: name, Namespace: instance.Namespace}, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// applyAPIGatewayConfig replaces the JSON annotations of the ingress by the fields set in the APIGatewayConfig.
+// Like the IngressClass defaults this only changes the in-memory object.
+func applyAPIGatewayConfig(instance *networkingv1.Ingress, config *v1alpha1.APIGatewayConfig) error {
+	if config == nil {
+		return nil
+	}
+
+	if instance.Annotations == nil {
+		instance.Annotations = map[string]string{}
+	}
+
+	annotations := map[string]interface{}{}
+	if config.Spec.AWSAPIDefinitions != nil {
+		annotations[IngressAnnotationAWSAPIConfigs] = toCFNAWSAPIDefinitions(config.Spec.AWSAPIDefinitions)
+	}
+	if config.Spec.PublicResources != nil {
+		annotations[IngressAnnotationPublicResources] = toCFNAPIResources(config.Spec.PublicResources)
+	}
+	if config.Spec.UsagePlans != nil {
+		annotations[IngressAnnotationAPIKeyBasedUsagePlans] = toCFNUsagePlans(config.Spec.UsagePlans)
+	}
+	if config.Spec.Canary != nil {
+		instance.Annotations[IngressAnnotationCanaryPercent] = formatPercent(config.Spec.Canary.PercentTraffic)
+		annotations[IngressAnnotationCanaryStageVariables] = config.Spec.Canary.StageVariableOverrides
+	}
+
+	for k, v := range annotations {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		instance.Annotations[k] = string(b)
+	}
+
+	return nil
+}
+
+func toCFNAWSAPIDefinitions(in []v1alpha1.AWSAPIDefinition) []cfn.AWSAPIDefinition {
+	out := make([]cfn.AWSAPIDefinition, 0, len(in))
+	for _, d := range in {
+		out = append(out, cfn.AWSAPIDefinition{
+			Name:                  d.Name,
+			Context:               d.Context,
+			AuthenticationEnabled: d.AuthenticationEnabled,
+			APIKeyEnabled:         d.APIKeyEnabled,
+			Authorization_Enabled: d.AuthorizationEnabled,
+			UsagePlans:            toCFNUsagePlans(d.UsagePlans),
+			Authorizers:           toCFNAuthorizers(d.Authorizers),
+			APIs:                  toCFNAPIResources(d.APIs),
+			BinaryMediaTypes:      d.BinaryMediaTypes,
+			LoggingLevel:          d.LoggingLevel,
+		})
+	}
+	return out
+}
+
+func toCFNAuthorizers(in []v1alpha1.AWSAPIAuthorizer) []cfn.AWSAPIAuthorizer {
+	if in == nil {
+		return nil
+	}
+	out := make([]cfn.AWSAPIAuthorizer, 0, len(in))
+	for _, a := range in {
+		out = append(out, cfn.AWSAPIAuthorizer{
+			IdentitySource:               a.IdentitySource,
+			AuthorizerType:               a.AuthorizerType,
+			AuthorizerAuthType:           a.AuthorizerAuthType,
+			AuthorizerName:               a.AuthorizerName,
+			IdentityValidationExpression: a.IdentityValidationExpression,
+			AuthorizerResultTtlInSeconds: a.AuthorizerResultTTLInSeconds,
+			AuthorizerUri:                a.AuthorizerURI,
+			ProviderARNs:                 a.ProviderARNs,
+		})
+	}
+	return out
+}
+
+func toCFNAPIResources(in []v1alpha1.APIResource) []cfn.APIResource {
+	if in == nil {
+		return nil
+	}
+	out := make([]cfn.APIResource, 0, len(in))
+	for _, res := range in {
+		var methods []cfn.Method
+		for _, m := range res.Methods {
+			methods = append(methods, cfn.Method{
+				Method:                m.Method,
+				APIKeyEnabled:         m.APIKeyEnabled,
+				Authorization_Enabled: m.AuthorizationEnabled,
+				Authorizator_Index:    m.AuthorizerIndex,
+				Authorization_Scopes:  m.AuthorizationScopes,
+			})
+		}
+		out = append(out, cfn.APIResource{
+			Path:              res.Path,
+			CachingEnabled:    res.CachingEnabled,
+			CacheTtlInSeconds: res.CacheTTLInSeconds,
+			Methods:           methods,
+			PathParams:        toCFNConstantParams(res.ConstantPathParams),
+			QueryParams:       toCFNConstantParams(res.ConstantQueryParams),
+			HeaderParams:      toCFNConstantParams(res.ConstantHeaderParams),
+			ProxyPathParams:   toCFNParams(res.PathParams),
+			ProxyQueryParams:  toCFNParams(res.QueryParams),
+			ProxyHeaderParams: toCFNParams(res.HeaderParams),
+			Type:              res.Type,
+			LambdaArn:         res.LambdaArn,
+		})
+	}
+	return out
+}
+
+func toCFNConstantParams(in []v1alpha1.ConstantParam) []cfn.ConstantParam {
+	if in == nil {
+		return nil
+	}
+	out := make([]cfn.ConstantParam, 0, len(in))
+	for _, p := range in {
+		out = append(out, cfn.ConstantParam{Key: p.Key, Value: p.Value})
+	}
+	return out
+}
+
+func toCFNParams(in []v1alpha1.Param) []cfn.Param {
+	if in == nil {
+		return nil
+	}
+	out := make([]cfn.Param, 0, len(in))
+	for _, p := range in {
+		out = append(out, cfn.Param{Param: p.Param, Required: p.Required, MappingParam: p.MappingParam})
+	}
+	return out
+}
+
+func toCFNUsagePlans(in []v1alpha1.UsagePlanConfig) []cfn.UsagePlan {
+	if in == nil {
+		return nil
+	}
+	out := make([]cfn.UsagePlan, 0, len(in))
+	for _, p := range in {
+		var keys []cfn.APIKey
+		for _, k := range p.APIKeys {
+			keys = append(keys, cfn.APIKey{CustomerID: k.CustomerID, GenerateDistinctID: k.GenerateDistinctID, Name: k.Name})
+		}
+		var throttling []cfn.MethodThrottlingParametersObject
+		for _, t := range p.MethodThrottlingParameters {
+			throttling = append(throttling, cfn.MethodThrottlingParametersObject{Path: t.Path, BurstLimit: t.BurstLimit, RateLimit: t.RateLimit})
+		}
+		out = append(out, cfn.UsagePlan{
+			PlanName:                   p.PlanName,
+			Description:                p.Description,
+			APIKeys:                    keys,
+			QuotaLimit:                 p.QuotaLimit,
+			QuotaOffset:                p.QuotaOffset,
+			QuotaPeriod:                p.QuotaPeriod,
+			ThrottleBurstLimit:         p.ThrottleBurstLimit,
+			ThrottleRateLimit:          p.ThrottleRateLimit,
+			MethodThrottlingParameters: throttling,
+		})
+	}
+	return out
+}
+
+// mapAPIGatewayConfigToIngresses enqueues the ingresses referencing an APIGatewayConfig
+func (r *ReconcileIngress) mapAPIGatewayConfigToIngresses(obj client.Object) []reconcile.Request {
+	ingresses, err := r.listIngresses()
+	if err != nil {
+		r.log.Error("unable to list ingresses", zap.Error(err))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, instance := range ingresses {
+		if instance.Namespace == obj.GetNamespace() && instance.Annotations[IngressAnnotationConfig] == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}})
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,127 @@
+package ingress
+
+import (
+	"context"
+	"strconv"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// backendKey is the key of the resolved port of a backend, see resolveBackendServicePorts
+func backendKey(backend *networkingv1.IngressServiceBackend) string {
+	if backend.Port.Name != "" {
+		return backendServicePortKey(backend.Name, backend.Port.Name)
+	}
+
+	return backendServicePortKey(backend.Name, strconv.Itoa(int(backend.Port.Number)))
+}
+
+// resolveBackendServicePorts looks up the Service of every ingress path and the number of the port it references,
+// keyed by backendKey. Backends whose Service or port doesn't exist are reported in the error list and left out,
+// the reverse proxy answers them with 503 as nginx doesn't start with an upstream it can't resolve.
+func (r *ReconcileIngress) resolveBackendServicePorts(instance *networkingv1.Ingress) (map[string]int32, field.ErrorList, error) {
+	return backendServicePorts(instance, func(name string) (*corev1.Service, error) {
+		svc := &corev1.Service{}
+		if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: name, Namespace: instance.Namespace}, svc); err != nil {
+			return nil, err
+		}
+		return svc, nil
+	})
+}
+
+// backendServicePorts resolves the backend ports with the Services returned by getService, which returns a NotFound
+// error for Services that don't exist
+func backendServicePorts(instance *networkingv1.Ingress, getService func(name string) (*corev1.Service, error)) (map[string]int32, field.ErrorList, error) {
+	ports := map[string]int32{}
+	var errs field.ErrorList
+	for i, rule := range instance.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for j, path := range rule.HTTP.Paths {
+			backend := path.Backend.Service
+			if backend == nil {
+				continue
+			}
+			fldPath := field.NewPath("spec", "rules").Index(i).Child("http", "paths").Index(j).Child("backend", "service")
+
+			svc, err := getService(backend.Name)
+			if errors.IsNotFound(err) {
+				errs = append(errs, field.NotFound(fldPath.Child("name"), backend.Name))
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+
+			port, ferr := resolveServicePort(svc, backend.Port, fldPath.Child("port"))
+			if ferr != nil {
+				errs = append(errs, ferr)
+				continue
+			}
+			ports[backendKey(backend)] = port
+		}
+	}
+
+	return ports, errs, nil
+}
+
+// resolveServicePort returns the number of the Service port referenced by name or number
+func resolveServicePort(svc *corev1.Service, port networkingv1.ServiceBackendPort, fldPath *field.Path) (int32, *field.Error) {
+	for _, servicePort := range svc.Spec.Ports {
+		if port.Name != "" && servicePort.Name == port.Name {
+			return servicePort.Port, nil
+		}
+		if port.Name == "" && servicePort.Port == port.Number {
+			return servicePort.Port, nil
+		}
+	}
+
+	if port.Name != "" {
+		return 0, field.NotFound(fldPath.Child("name"), port.Name)
+	}
+	return 0, field.NotFound(fldPath.Child("number"), port.Number)
+}
+
+// mapServiceToIngresses enqueues the ingresses with a path backed by the Service
+func (r *ReconcileIngress) mapServiceToIngresses(obj client.Object) []reconcile.Request {
+	ingresses, err := r.listIngresses()
+	if err != nil {
+		r.log.Error("unable to list ingresses", zap.Error(err))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, instance := range ingresses {
+		if instance.Namespace != obj.GetNamespace() || !referencesService(instance, obj.GetName()) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}})
+	}
+
+	return requests
+}
+
+func referencesService(instance *networkingv1.Ingress, name string) bool {
+	for _, rule := range instance.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil && path.Backend.Service.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,200 @@
+package ingress
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/apigateway"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// IngressAnnotationCanaryPercent deploys changes as canaries receiving this percentage of the stage traffic
+	IngressAnnotationCanaryPercent = "apigateway.ingress.kubernetes.io/canary-percent"
+	// IngressAnnotationCanaryStageVariables is a JSON object of the stage variables that differ for the canary
+	IngressAnnotationCanaryStageVariables = "apigateway.ingress.kubernetes.io/canary-stage-variables"
+	// IngressAnnotationCanaryAction promotes or rolls back the canaries, the controller removes it once done
+	IngressAnnotationCanaryAction = "apigateway.ingress.kubernetes.io/canary-action"
+	// IngressAnnotationCanaryStatus reports the canaries of the stages
+	IngressAnnotationCanaryStatus = "apigateway.ingress.kubernetes.io/canary-status"
+
+	// CanaryActionPromote moves the stages to their canary deployment with the canary stage variables
+	CanaryActionPromote = "Promote"
+	// CanaryActionRollback removes the canaries, the stages keep serving their deployment
+	CanaryActionRollback = "Rollback"
+)
+
+var supportedCanaryActions = []string{CanaryActionPromote, CanaryActionRollback}
+
+// parseCanaryPercent returns false if the annotation isn't set
+func parseCanaryPercent(ingress *networkingv1.Ingress) (float64, bool, *field.Error) {
+	v := ingress.ObjectMeta.Annotations[IngressAnnotationCanaryPercent]
+	if v == "" {
+		return 0, false, nil
+	}
+
+	percent, err := strconv.ParseFloat(v, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, false, field.Invalid(annotationsPath.Key(IngressAnnotationCanaryPercent), v, "must be a number between 0 and 100")
+	}
+
+	return percent, true, nil
+}
+
+func getCanaryAction(ingress *networkingv1.Ingress) string {
+	v, _ := parseEnumAnnotation(ingress, IngressAnnotationCanaryAction, "", supportedCanaryActions)
+	return v
+}
+
+// getDeploymentCanarySettings returns nil unless the ingress deploys changes as canaries
+func getDeploymentCanarySettings(ingress *networkingv1.Ingress) *apigateway.DeploymentCanarySettings {
+	percent, ok, _ := parseCanaryPercent(ingress)
+	if !ok {
+		return nil
+	}
+
+	variables := map[string]string{}
+	_ = parseJSONAnnotation(ingress, IngressAnnotationCanaryStageVariables, &variables)
+	return &apigateway.DeploymentCanarySettings{
+		PercentTraffic:         aws.Float64(percent),
+		StageVariableOverrides: aws.StringMap(variables),
+	}
+}
+
+// reconcileCanary runs once the RestApis are deployed. It applies the canary action of the ingress to the canaries of
+// the stages, or else keeps their percentage and stage variables in line with the annotations, and reports them in
+// the canary-status annotation. Canaries of an ingress without canary-percent are rolled back.
+func (r *ReconcileIngress) reconcileCanary(instance *networkingv1.Ingress, stack *cloudformation.Stack) error {
+	stageName := getStageName(instance)
+	action := getCanaryAction(instance)
+	settings := getDeploymentCanarySettings(instance)
+	state := getDeploymentState(instance)
+	stateChanged := false
+
+	var statuses []string
+	for _, id := range restAPIIDs(stack) {
+		stage, err := r.apigatewaySvc.GetStage(&apigateway.GetStageInput{RestApiId: aws.String(id), StageName: aws.String(stageName)})
+		if isAPIGatewayNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if stage.CanarySettings == nil || aws.StringValue(stage.CanarySettings.DeploymentId) == "" {
+			continue
+		}
+		canaryID := aws.StringValue(stage.CanarySettings.DeploymentId)
+
+		// Without canary-percent nothing keeps the canary in line with the ingress anymore
+		rollback := action == CanaryActionRollback || (action == "" && settings == nil)
+
+		var ops []*apigateway.PatchOperation
+		switch {
+		case action == CanaryActionPromote:
+			ops = promoteCanaryOperations(stage.CanarySettings)
+		case rollback:
+			ops = []*apigateway.PatchOperation{{Op: aws.String(apigateway.OpRemove), Path: aws.String("/canarySettings")}}
+		default:
+			ops = updateCanaryOperations(stage.CanarySettings, settings)
+		}
+
+		if len(ops) > 0 {
+			if _, err := r.apigatewaySvc.UpdateStage(&apigateway.UpdateStageInput{
+				RestApiId:       aws.String(id),
+				StageName:       aws.String(stageName),
+				PatchOperations: ops,
+			}); err != nil {
+				r.event(instance, corev1.EventTypeWarning, EventReasonCanaryFailed, "unable to update the canary of RestApi %s stage %s: %v", id, stageName, err)
+				return err
+			}
+		}
+
+		switch {
+		case action == CanaryActionPromote:
+			r.log.Info("promoted canary", zap.String("restApiId", id), zap.String("deploymentId", canaryID))
+			r.event(instance, corev1.EventTypeNormal, EventReasonCanaryPromoted, "promoted canary deployment %s of RestApi %s stage %s", canaryID, id, stageName)
+		case rollback:
+			reason := ""
+			if action == "" {
+				reason = ", " + IngressAnnotationCanaryPercent + " is not set"
+			}
+			r.log.Info("rolled back canary", zap.String("restApiId", id), zap.String("deploymentId", canaryID))
+			r.event(instance, corev1.EventTypeNormal, EventReasonCanaryRolledBack, "rolled back canary deployment %s of RestApi %s stage %s%s", canaryID, id, stageName, reason)
+			// The rolled back template is deployed again once it changes, not right away
+			if state.deploymentIDs[id] == canaryID {
+				state.deploymentIDs[id] = aws.StringValue(stage.DeploymentId)
+				stateChanged = true
+			}
+		default:
+			statuses = append(statuses, fmt.Sprintf("%s: %s%% to canary deployment %s, the rest to %s", id, formatPercent(aws.Float64Value(settings.PercentTraffic)), canaryID, aws.StringValue(stage.DeploymentId)))
+		}
+	}
+
+	if stateChanged {
+		if err := r.updateIngressAnnotation(instance, IngressAnnotationDeployments, state.String()); err != nil {
+			return err
+		}
+	}
+	if action != "" {
+		if err := r.updateIngressAnnotation(instance, IngressAnnotationCanaryAction, ""); err != nil {
+			return err
+		}
+	}
+	if status := strings.Join(statuses, "; "); status != instance.Annotations[IngressAnnotationCanaryStatus] {
+		return r.updateIngressAnnotation(instance, IngressAnnotationCanaryStatus, status)
+	}
+
+	return nil
+}
+
+// promoteCanaryOperations move the stage to the canary deployment, with the canary stage variables
+func promoteCanaryOperations(canary *apigateway.CanarySettings) []*apigateway.PatchOperation {
+	ops := []*apigateway.PatchOperation{{Op: aws.String(apigateway.OpReplace), Path: aws.String("/deploymentId"), Value: canary.DeploymentId}}
+	for _, name := range sortedStringKeys(canary.StageVariableOverrides) {
+		ops = append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpReplace), Path: aws.String("/variables/" + name), Value: canary.StageVariableOverrides[name]})
+	}
+
+	return append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpRemove), Path: aws.String("/canarySettings")})
+}
+
+// updateCanaryOperations change the percentage and stage variables of the live canary to the desired ones
+func updateCanaryOperations(canary *apigateway.CanarySettings, desired *apigateway.DeploymentCanarySettings) []*apigateway.PatchOperation {
+	var ops []*apigateway.PatchOperation
+	if percent := aws.Float64Value(desired.PercentTraffic); percent != aws.Float64Value(canary.PercentTraffic) {
+		ops = append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpReplace), Path: aws.String("/canarySettings/percentTraffic"), Value: aws.String(formatPercent(percent))})
+	}
+
+	for _, name := range sortedStringKeys(desired.StageVariableOverrides) {
+		if value := aws.StringValue(desired.StageVariableOverrides[name]); canary.StageVariableOverrides[name] == nil || value != aws.StringValue(canary.StageVariableOverrides[name]) {
+			ops = append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpReplace), Path: aws.String("/canarySettings/stageVariableOverrides/" + name), Value: aws.String(value)})
+		}
+	}
+	for _, name := range sortedStringKeys(canary.StageVariableOverrides) {
+		if _, ok := desired.StageVariableOverrides[name]; !ok {
+			ops = append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpRemove), Path: aws.String("/canarySettings/stageVariableOverrides/" + name)})
+		}
+	}
+
+	return ops
+}
+
+func formatPercent(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', -1, 64)
+}
+
+func sortedStringKeys(m map[string]*string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
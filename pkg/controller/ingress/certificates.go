@@ -0,0 +1,335 @@
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	SecretAnnotationACMCertificateArn  = "apigateway.ingress.kubernetes.io/acm-certificate-arn"
+	SecretAnnotationACMCertificateHash = "apigateway.ingress.kubernetes.io/acm-certificate-hash"
+	// ACMEdgeRegion is the region edge optimized custom domains read their certificate from
+	ACMEdgeRegion = "us-east-1"
+)
+
+// acmFor returns the ACM client of the region the custom domains of the ingress need their certificates in and the
+// suffix of the Secret annotations tracking the import there
+func (r *ReconcileIngress) acmFor(instance *networkingv1.Ingress) (acmiface.ACMAPI, string) {
+	if getAPIEndpointType(instance) == "REGIONAL" {
+		return r.acmSvc, "regional"
+	}
+
+	return r.acmEdgeSvc, "edge"
+}
+
+// splitCertificateChain splits a PEM bundle into the leaf certificate and the rest of the chain
+func splitCertificateChain(bundle []byte) ([]byte, []byte, error) {
+	block, rest := pem.Decode(bundle)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, nil, fmt.Errorf("no PEM certificate found")
+	}
+
+	return pem.EncodeToMemory(block), bytes.TrimSpace(rest), nil
+}
+
+func certificateHash(cert []byte, key []byte) string {
+	h := sha256.New()
+	h.Write(cert)
+	h.Write(key)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// acmForSuffix returns the ACM client of the Secret annotations with the suffix
+func (r *ReconcileIngress) acmForSuffix(suffix string) acmiface.ACMAPI {
+	if suffix == "regional" {
+		return r.acmSvc
+	}
+
+	return r.acmEdgeSvc
+}
+
+// importTLSCertificate imports the TLS Secret into ACM, or re-imports it under the same ARN once its content changed,
+// e.g. after a renewal. The ARN and a hash of the imported content are kept as annotations of the Secret.
+// New certificates are tagged with the cluster and the ingress that imported them.
+func (r *ReconcileIngress) importTLSCertificate(instance *networkingv1.Ingress, acmSvc acmiface.ACMAPI, suffix string, secret *corev1.Secret) (string, error) {
+	cert, key := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+	if len(cert) == 0 || len(key) == 0 {
+		return "", fmt.Errorf("secret %s/%s has no %s or %s", secret.Namespace, secret.Name, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	}
+
+	arnAnnotation := fmt.Sprintf("%s-%s", SecretAnnotationACMCertificateArn, suffix)
+	hashAnnotation := fmt.Sprintf("%s-%s", SecretAnnotationACMCertificateHash, suffix)
+	arn := secret.Annotations[arnAnnotation]
+	hash := certificateHash(cert, key)
+	if arn != "" && secret.Annotations[hashAnnotation] == hash {
+		return arn, nil
+	}
+
+	leaf, chain, err := splitCertificateChain(cert)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s of secret %s/%s: %v", corev1.TLSCertKey, secret.Namespace, secret.Name, err)
+	}
+
+	input := &acm.ImportCertificateInput{
+		Certificate: leaf,
+		PrivateKey:  key,
+	}
+	if len(chain) > 0 {
+		input.CertificateChain = chain
+	}
+
+	if arn != "" {
+		r.log.Info("re-importing certificate into ACM", zap.String("secret", secret.Name), zap.String("arn", arn))
+		input.CertificateArn = aws.String(arn)
+		out, err := acmSvc.ImportCertificate(input)
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == acm.ErrCodeResourceNotFoundException {
+			r.log.Info("certificate was removed from ACM, importing it again", zap.String("arn", arn))
+			input.CertificateArn = nil
+		} else if err != nil {
+			return "", err
+		} else {
+			arn = aws.StringValue(out.CertificateArn)
+		}
+	}
+
+	if input.CertificateArn == nil {
+		r.log.Info("importing certificate into ACM", zap.String("secret", secret.Name))
+		input.Tags = []*acm.Tag{
+			{
+				Key:   aws.String(StackTagManagedBy),
+				Value: aws.String(stackManagedBy),
+			},
+			{
+				Key:   aws.String("kubernetes.io/secret"),
+				Value: aws.String(fmt.Sprintf("%s/%s", secret.Namespace, secret.Name)),
+			},
+			{
+				Key:   aws.String(StackTagClusterID),
+				Value: aws.String(ClusterID),
+			},
+			{
+				Key:   aws.String(StackTagNamespace),
+				Value: aws.String(instance.Namespace),
+			},
+			{
+				Key:   aws.String(StackTagIngress),
+				Value: aws.String(instance.Name),
+			},
+		}
+		out, err := acmSvc.ImportCertificate(input)
+		if err != nil {
+			return "", err
+		}
+		arn = aws.StringValue(out.CertificateArn)
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[arnAnnotation] = arn
+	secret.Annotations[hashAnnotation] = hash
+	if err := r.Update(context.TODO(), secret); err != nil {
+		return "", err
+	}
+
+	return arn, nil
+}
+
+// plannedCertificateArn is the ARN a dry run plans with instead of importing the Secret: the one it was imported
+// under, which a re-import keeps, or a placeholder if it was never imported
+func plannedCertificateArn(suffix string, secret *corev1.Secret) string {
+	if arn := secret.Annotations[fmt.Sprintf("%s-%s", SecretAnnotationACMCertificateArn, suffix)]; arn != "" {
+		return arn
+	}
+
+	return fmt.Sprintf("pending-import:%s/%s", secret.Namespace, secret.Name)
+}
+
+// importTLSCertificates imports the Secrets of the ingress spec.tls into ACM and returns the certificate ARN by TLS host.
+// TLS entries without hosts are returned under the empty host and serve as default certificate.
+func (r *ReconcileIngress) importTLSCertificates(instance *networkingv1.Ingress) (map[string]string, error) {
+	if len(instance.Spec.TLS) == 0 {
+		return nil, nil
+	}
+
+	acmSvc, suffix := r.acmFor(instance)
+	arns := map[string]string{}
+	for _, tls := range instance.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: tls.SecretName, Namespace: instance.Namespace}, secret); err != nil {
+			r.log.Error("unable to fetch TLS secret", zap.String("secret", tls.SecretName), zap.Error(err))
+			return nil, err
+		}
+
+		var arn string
+		var err error
+		if isDryRun(instance) {
+			arn = plannedCertificateArn(suffix, secret)
+		} else {
+			arn, err = r.importTLSCertificate(instance, acmSvc, suffix, secret)
+		}
+		if err != nil {
+			r.log.Error("unable to import TLS secret into ACM", zap.String("secret", tls.SecretName), zap.Error(err))
+			return nil, err
+		}
+
+		if len(tls.Hosts) == 0 {
+			arns[""] = arn
+		}
+		for _, host := range tls.Hosts {
+			arns[host] = arn
+		}
+	}
+
+	return arns, nil
+}
+
+// deleteUnusedCertificates deletes the certificates the ingress imported from TLS Secrets that no ingress of the
+// namespace uses any more, because they were deleted or dropped the Secrets from spec.tls. Certificates imported by
+// other ingresses or clusters are left alone, they are told apart by their tags. It runs once an update of the stack
+// finished and once the stack is deleted; a certificate still in use by another stack is kept.
+func (r *ReconcileIngress) deleteUnusedCertificates(instance *networkingv1.Ingress) error {
+	secrets := &corev1.SecretList{}
+	if err := r.List(context.TODO(), secrets, client.InNamespace(instance.Namespace)); err != nil {
+		return err
+	}
+
+	ingresses, err := r.listIngresses()
+	if err != nil {
+		return err
+	}
+
+	// Other ingresses keep the certificates of every region, their endpoint type may come from defaults
+	used := map[string]bool{}
+	for _, ingress := range ingresses {
+		if ingress.Namespace != instance.Namespace || ingress.Name == instance.Name {
+			continue
+		}
+		for _, tls := range ingress.Spec.TLS {
+			used[tls.SecretName] = true
+		}
+	}
+	_, instanceSuffix := r.acmFor(instance)
+	deleting := !instance.ObjectMeta.DeletionTimestamp.IsZero()
+
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if used[secret.Name] {
+			continue
+		}
+
+		changed := false
+		for _, suffix := range []string{"regional", "edge"} {
+			arnAnnotation := fmt.Sprintf("%s-%s", SecretAnnotationACMCertificateArn, suffix)
+			arn := secret.Annotations[arnAnnotation]
+			if arn == "" || !deleting && suffix == instanceSuffix && usesTLSSecret(instance, secret.Name) {
+				continue
+			}
+
+			acmSvc := r.acmForSuffix(suffix)
+			owned, err := importedBy(acmSvc, arn, instance)
+			if err != nil {
+				return err
+			}
+			if !owned {
+				continue
+			}
+
+			r.log.Info("deleting unused certificate from ACM", zap.String("secret", secret.Name), zap.String("arn", arn))
+			_, err = acmSvc.DeleteCertificate(&acm.DeleteCertificateInput{CertificateArn: aws.String(arn)})
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == acm.ErrCodeResourceInUseException {
+				r.log.Info("certificate is still in use, keeping it", zap.String("arn", arn))
+				continue
+			}
+			if aerr, ok := err.(awserr.Error); err != nil && !(ok && aerr.Code() == acm.ErrCodeResourceNotFoundException) {
+				r.log.Error("unable to delete certificate", zap.String("arn", arn), zap.Error(err))
+				return err
+			}
+			r.event(instance, corev1.EventTypeNormal, EventReasonCertificateDeleted, "deleted certificate %s imported from secret %s", arn, secret.Name)
+
+			delete(secret.Annotations, arnAnnotation)
+			delete(secret.Annotations, fmt.Sprintf("%s-%s", SecretAnnotationACMCertificateHash, suffix))
+			changed = true
+		}
+
+		if changed {
+			if err := r.Update(context.TODO(), secret); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// importedBy reports whether the tags of the certificate name the controller, the cluster and the ingress
+func importedBy(acmSvc acmiface.ACMAPI, arn string, instance *networkingv1.Ingress) (bool, error) {
+	out, err := acmSvc.ListTagsForCertificate(&acm.ListTagsForCertificateInput{CertificateArn: aws.String(arn)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == acm.ErrCodeResourceNotFoundException {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	tags := map[string]string{}
+	for _, tag := range out.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	return tags[StackTagManagedBy] == stackManagedBy && tags[StackTagClusterID] == ClusterID &&
+		tags[StackTagNamespace] == instance.Namespace && tags[StackTagIngress] == instance.Name, nil
+}
+
+func usesTLSSecret(instance *networkingv1.Ingress, name string) bool {
+	for _, tls := range instance.Spec.TLS {
+		if tls.SecretName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapSecretToIngresses enqueues the ingresses using a Secret in spec.tls
+func (r *ReconcileIngress) mapSecretToIngresses(obj client.Object) []reconcile.Request {
+	ingresses, err := r.listIngresses()
+	if err != nil {
+		r.log.Error("unable to list ingresses", zap.Error(err))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, instance := range ingresses {
+		if instance.Namespace != obj.GetNamespace() {
+			continue
+		}
+		for _, tls := range instance.Spec.TLS {
+			if tls.SecretName == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}})
+				break
+			}
+		}
+	}
+
+	return requests
+}
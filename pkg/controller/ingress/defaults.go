@@ -0,0 +1,156 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// DefaultsConfigMapName is the ConfigMap in IngressClassParametersNamespace holding the controller defaults,
+	// it is re-read on every change. Empty disables it.
+	DefaultsConfigMapName = ""
+
+	// fileDefaults are the controller defaults of the --defaults-file, the ConfigMap overrides them
+	fileDefaults map[string]string
+
+	// loggedDefaults are the effective controller defaults last logged
+	loggedDefaults   map[string]string
+	loggedDefaultsMu sync.Mutex
+
+	// controllerOwnedAnnotations are written by the controller, or per ingress by hand, and can't have defaults
+	controllerOwnedAnnotations = []string{
+		IngressAnnotationStackName,
+		IngressAnnotationStackRecoveryState,
+		IngressAnnotationRoute53StackRecoveryState,
+		IngressAnnotationPlan,
+		IngressAnnotationPendingChangeSet,
+		IngressAnnotationApprovedChangeSet,
+		IngressAnnotationDeployments,
+		IngressAnnotationCanaryAction,
+		IngressAnnotationCanaryStatus,
+	}
+)
+
+// LoadDefaultsFile reads the controller defaults from a YAML or JSON file mapping annotation names, with or without
+// the apigateway.ingress.kubernetes.io/ prefix, to their default values
+func LoadDefaultsFile(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("unable to parse defaults file %s: %v", path, err)
+	}
+
+	defaults := map[string]string{}
+	for k, v := range values {
+		switch v := v.(type) {
+		case string:
+			defaults[k] = v
+		case bool, float64:
+			defaults[k] = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("default %s of defaults file %s must be a string, number or bool", k, path)
+		}
+	}
+	if errs := validateDefaults(defaults); len(errs) > 0 {
+		return fmt.Errorf("invalid defaults file %s: %v", path, errs.ToAggregate())
+	}
+
+	fileDefaults = defaults
+	return nil
+}
+
+// getControllerDefaults returns the controller defaults of the file and the ConfigMap, the ConfigMap taking
+// precedence. Invalid ConfigMap defaults are an error rather than ignored, so that stacks are left alone until
+// they are fixed instead of falling back to other values.
+func (r *ReconcileIngress) getControllerDefaults() (map[string]string, error) {
+	defaults := map[string]string{}
+	for k, v := range fileDefaults {
+		defaults[normalizeAnnotationKey(k)] = v
+	}
+
+	if DefaultsConfigMapName != "" {
+		cm := &corev1.ConfigMap{}
+		err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: DefaultsConfigMapName, Namespace: IngressClassParametersNamespace}, cm)
+		if err != nil && !isMissing(err) {
+			return nil, err
+		}
+		if errs := validateDefaults(cm.Data); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid defaults in ConfigMap %s/%s: %v", IngressClassParametersNamespace, DefaultsConfigMapName, errs.ToAggregate())
+		}
+		for k, v := range cm.Data {
+			defaults[normalizeAnnotationKey(k)] = v
+		}
+	}
+
+	r.logDefaults(defaults)
+	return defaults, nil
+}
+
+// applyControllerDefaults sets every annotation neither the ingress nor its IngressClass set to the controller
+// default. Like the class defaults, it only changes the in-memory object.
+func applyControllerDefaults(instance *networkingv1.Ingress, defaults map[string]string) {
+	applyIngressClassParameters(instance, defaults)
+}
+
+// validateDefaults parses the defaults like the annotations of an ingress setting all of them
+func validateDefaults(defaults map[string]string) field.ErrorList {
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	instance := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+	var errs field.ErrorList
+	for k, v := range defaults {
+		key := normalizeAnnotationKey(k)
+		if contains(controllerOwnedAnnotations, key) {
+			errs = append(errs, field.Forbidden(annotationsPath.Key(key), "is set by the controller"))
+			continue
+		}
+		instance.Annotations[key] = v
+	}
+
+	return append(errs, parseAnnotations(instance)...)
+}
+
+// logDefaults logs the effective controller defaults whenever they change
+func (r *ReconcileIngress) logDefaults(defaults map[string]string) {
+	loggedDefaultsMu.Lock()
+	defer loggedDefaultsMu.Unlock()
+
+	if reflect.DeepEqual(defaults, loggedDefaults) {
+		return
+	}
+	loggedDefaults = defaults
+
+	keys := make([]string, 0, len(defaults))
+	for k := range defaults {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, zap.String(k, defaults[k]))
+	}
+	r.log.Info("controller defaults changed", fields...)
+}
+
+func normalizeAnnotationKey(k string) string {
+	return IngressAnnotationPrefix + strings.TrimPrefix(k, IngressAnnotationPrefix)
+}
@@ -0,0 +1,150 @@
+package ingress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/apigateway"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// IngressAnnotationDeployments records the deployments the controller created for the template the stack was last
+// updated with, as "<template hash>,<rest api id>=<deployment id>,..."
+const IngressAnnotationDeployments = "apigateway.ingress.kubernetes.io/deployments"
+
+// deploymentInterval spaces the deployments of an ingress, API Gateway throttles CreateDeployment hard
+var deploymentInterval = 6 * time.Second
+
+type deploymentState struct {
+	templateHash string
+	// deploymentIDs maps the RestApi ids to the deployment created for the template
+	deploymentIDs map[string]string
+}
+
+// getDeploymentState returns the empty state if the annotation is missing or malformed, which deploys every RestApi
+func getDeploymentState(instance *networkingv1.Ingress) deploymentState {
+	state := deploymentState{deploymentIDs: map[string]string{}}
+	parts := strings.Split(instance.ObjectMeta.Annotations[IngressAnnotationDeployments], ",")
+	if parts[0] == "" {
+		return state
+	}
+
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return deploymentState{deploymentIDs: map[string]string{}}
+		}
+		state.deploymentIDs[kv[0]] = kv[1]
+	}
+	state.templateHash = parts[0]
+
+	return state
+}
+
+func (s deploymentState) String() string {
+	parts := []string{s.templateHash}
+	ids := make([]string, 0, len(s.deploymentIDs))
+	for id := range s.deploymentIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		parts = append(parts, id+"="+s.deploymentIDs[id])
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// restAPIIDs returns the ids of the RestApis of the stack, one per API config or ingress host
+func restAPIIDs(stack *cloudformation.Stack) []string {
+	outputs := cfn.StackOutputMap(stack)
+	var ids []string
+	for i := 0; outputs[fmt.Sprintf("%s%d", cfn.OutputKeyRestAPIID, i)] != ""; i++ {
+		ids = append(ids, outputs[fmt.Sprintf("%s%d", cfn.OutputKeyRestAPIID, i)])
+	}
+
+	return ids
+}
+
+// deployRestAPIs deploys the RestApis of a complete stack to the stage, so that changes to their resources and
+// methods take effect. A RestApi is deployed once per stack template, and again if its stage was moved to another
+// deployment. With a canary-percent the changes are deployed as canaries of the stages, see reconcileCanary.
+// To stay within the CreateDeployment limits one RestApi is deployed per pass, the others are deployed by
+// requeues. It returns a nil result once every RestApi is deployed.
+func (r *ReconcileIngress) deployRestAPIs(instance *networkingv1.Ingress, stack *cloudformation.Stack) (*reconcile.Result, error) {
+	stageName := getStageName(instance)
+	state := getDeploymentState(instance)
+	// The first deployment of an ingress is never a canary, there is nothing to compare it with
+	canary := state.templateHash != ""
+	if hash := stackTemplateHash(stack); state.templateHash != hash {
+		state = deploymentState{templateHash: hash, deploymentIDs: map[string]string{}}
+	}
+
+	deployed := false
+	for _, id := range restAPIIDs(stack) {
+		stage, err := r.apigatewaySvc.GetStage(&apigateway.GetStageInput{RestApiId: aws.String(id), StageName: aws.String(stageName)})
+		if err != nil && !isAPIGatewayNotFound(err) {
+			return nil, err
+		}
+		if state.deploymentIDs[id] != "" && stage != nil && (aws.StringValue(stage.DeploymentId) == state.deploymentIDs[id] ||
+			stage.CanarySettings != nil && aws.StringValue(stage.CanarySettings.DeploymentId) == state.deploymentIDs[id]) {
+			continue
+		}
+
+		if deployed {
+			return &reconcile.Result{RequeueAfter: deploymentInterval}, nil
+		}
+
+		input := &apigateway.CreateDeploymentInput{
+			RestApiId: aws.String(id),
+			StageName: aws.String(stageName),
+		}
+		if canary && stage != nil {
+			input.CanarySettings = getDeploymentCanarySettings(instance)
+		}
+
+		r.log.Info("creating apigateway deployment", zap.String("restApiId", id), zap.String("stage", stageName), zap.Bool("canary", input.CanarySettings != nil))
+		deployment, err := r.apigatewaySvc.CreateDeployment(input)
+		if isAPIGatewayThrottled(err) {
+			r.log.Info("deployment throttled, requeuing", zap.String("restApiId", id))
+			return &reconcile.Result{RequeueAfter: deploymentInterval}, nil
+		}
+		if err != nil {
+			r.log.Error("unable to deploy ApiGateway Rest API", zap.Error(err))
+			r.event(instance, corev1.EventTypeWarning, EventReasonDeployFailed, "unable to deploy RestApi %s to stage %s: %v", id, stageName, err)
+			return nil, err
+		}
+		if input.CanarySettings != nil {
+			r.event(instance, corev1.EventTypeNormal, EventReasonDeployed, "deployed RestApi %s to the canary of stage %s at %s%%", id, stageName, formatPercent(aws.Float64Value(input.CanarySettings.PercentTraffic)))
+		} else {
+			r.event(instance, corev1.EventTypeNormal, EventReasonDeployed, "deployed RestApi %s to stage %s", id, stageName)
+		}
+
+		state.deploymentIDs[id] = aws.StringValue(deployment.Id)
+		if err := r.updateIngressAnnotation(instance, IngressAnnotationDeployments, state.String()); err != nil {
+			return nil, err
+		}
+		deployed = true
+	}
+
+	return nil, nil
+}
+
+func isAPIGatewayNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == apigateway.ErrCodeNotFoundException
+}
+
+func isAPIGatewayThrottled(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == apigateway.ErrCodeTooManyRequestsException
+}
@@ -0,0 +1,67 @@
+package ingress
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Reasons of the Events recorded on ingresses
+const (
+	EventReasonInvalidIngress         = "InvalidIngress"
+	EventReasonInvalidBackend         = "InvalidBackend"
+	EventReasonCreatingStack          = "CreatingStack"
+	EventReasonUpdatingStack          = "UpdatingStack"
+	EventReasonDeletingStack          = "DeletingStack"
+	EventReasonStackFailed            = "StackFailed"
+	EventReasonDeployed               = "Deployed"
+	EventReasonDeployFailed           = "DeployFailed"
+	EventReasonCreatingRoute53        = "CreatingRoute53Stack"
+	EventReasonUpdatingRoute53        = "UpdatingRoute53Stack"
+	EventReasonDeletingRoute53        = "DeletingRoute53Stack"
+	EventReasonRoute53StackFailed     = "Route53StackFailed"
+	EventReasonStackOperationError    = "StackOperationError"
+	EventReasonRecoveringStack        = "RecoveringStack"
+	EventReasonStackRecoveryExhausted = "StackRecoveryExhausted"
+	EventReasonAdoptedStack           = "AdoptedStack"
+	EventReasonPlannedChanges         = "PlannedChanges"
+	EventReasonAwaitingApproval       = "AwaitingApproval"
+	EventReasonExecutingChangeSet     = "ExecutingChangeSet"
+	EventReasonCanaryPromoted         = "CanaryPromoted"
+	EventReasonCanaryRolledBack       = "CanaryRolledBack"
+	EventReasonCanaryFailed           = "CanaryFailed"
+	EventReasonCertificateDeleted     = "CertificateDeleted"
+)
+
+// event records an Event on the ingress in the version the apiserver serves. Reconcilers built without a recorder,
+// like most in unit tests, don't record anything.
+func (r *ReconcileIngress) event(instance *networkingv1.Ingress, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.recorder == nil {
+		return
+	}
+
+	r.recorder.Eventf(r.ingressOwner(instance).(runtime.Object), eventType, reason, messageFmt, args...)
+}
+
+// reportStackFailure records a warning with the resource that made the stack fail and its status reason
+func (r *ReconcileIngress) reportStackFailure(instance *networkingv1.Ingress, cfnSvc cloudformationiface.CloudFormationAPI, reason string, stackName string, status string) {
+	failed, err := cfn.FirstFailedStackEvent(cfnSvc, stackName)
+	if err != nil {
+		r.log.Error("unable to describe stack events", zap.String("stackName", stackName), zap.Error(err))
+	}
+	if failed == nil {
+		r.event(instance, corev1.EventTypeWarning, reason, "stack %s is %s", stackName, status)
+		return
+	}
+
+	message := fmt.Sprintf("stack %s is %s: %s %s: %s", stackName, status,
+		aws.StringValue(failed.LogicalResourceId), aws.StringValue(failed.ResourceStatus), aws.StringValue(failed.ResourceStatusReason))
+	r.log.Error("stack failed", zap.String("stackName", stackName), zap.String("reason", message))
+	r.event(instance, corev1.EventTypeWarning, reason, "%s", message)
+}
@@ -17,12 +17,10 @@ package ingress
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/url"
 	"os"
-	"reflect"
 	"strings"
 	"time"
 
@@ -30,8 +28,9 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
-	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
 	"github.com/aws/aws-sdk-go/service/apigateway"
 	"github.com/aws/aws-sdk-go/service/apigateway/apigatewayiface"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
@@ -40,7 +39,12 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/apis/apigateway/v1alpha1"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/awssession"
 	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
 	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/finalizers"
 	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/logging"
@@ -48,11 +52,14 @@ import (
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -63,7 +70,6 @@ import (
 )
 
 const (
-	ingressNameLengthLimit                  = 51
 	FinalizerCFNStack                       = "apigateway.networking.amazonaws.com/ingress-finalizer"
 	FinalizerRoute53CFNStack                = "apigateway.networking.amazonaws.com/route53-ingress-finalizer"
 	IngressClassAnnotation                  = "kubernetes.io/ingress.class"
@@ -106,40 +112,22 @@ var (
 // Add creates a new Ingress Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
-}
-
-func getAWSSession(logger *zap.Logger) *session.Session {
-	logger.Info("creating session for ec2metadata service")
-	sess, err := session.NewSession(&aws.Config{Region: aws.String("us-west-2")})
-	if err != nil {
-		logger.Fatal("unable create session for ec2 metadata service call", zap.Error(err))
-	}
-
-	ec2metadataSvc := ec2metadata.New(sess)
-	logger.Info("fetching ec2 identity document")
-	ec2IdentityDocument, err := ec2metadataSvc.GetInstanceIdentityDocument()
+	r, err := newReconciler(mgr)
 	if err != nil {
-		logger.Fatal("unable to determine region from ec2", zap.Error(err))
-	}
-
-	logger.Info("creating AWS api session", zap.String("region", ec2IdentityDocument.Region))
-	sess, err = session.NewSession(&aws.Config{
-		Region: aws.String(ec2IdentityDocument.Region),
-	})
-
-	if err != nil {
-		logger.Fatal("unable to create session for AWS services", zap.Error(err))
+		return err
 	}
 
-	return sess
+	return add(mgr, r)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager) (*ReconcileIngress, error) {
 	logger := logging.New()
 
-	sess := getAWSSession(logger)
+	sess, err := awssession.New(logger)
+	if err != nil {
+		return nil, err
+	}
 
 	return &ReconcileIngress{
 		Client:         mgr.GetClient(),
@@ -149,33 +137,87 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 		ec2Svc:         ec2.New(sess),
 		apigatewaySvc:  apigateway.New(sess),
 		autoscalingSvc: autoscaling.New(sess),
-		s3Uploader:     s3manager.NewUploader(sess),
-	}
+		elbv2Svc:       elbv2.New(sess),
+		acmSvc:         acm.New(sess),
+		acmEdgeSvc:     acm.New(sess, aws.NewConfig().WithRegion(ACMEdgeRegion)),
+		s3Uploader:     newS3Uploader(sess),
+		sess:           sess,
+		recorder:       mgr.GetEventRecorderFor("apigateway-ingress-controller"),
+		legacyIngress:  !servesNetworkingV1Ingress(mgr.GetRESTMapper()),
+	}, nil
+}
+
+// newS3Uploader uploads path style, so that the Location of uploads is a template URL on the resolved S3 endpoint
+// of the region, partition or endpoint override
+func newS3Uploader(sess *session.Session) *s3manager.Uploader {
+	return s3manager.NewUploaderWithClient(s3.New(sess, aws.NewConfig().WithS3ForcePathStyle(true)))
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r *ReconcileIngress) error {
 	// Create a new controller
 	c, err := controller.New("ingress-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
 		return err
 	}
 
-	// Watch for changes to Ingress
-	err = c.Watch(&source.Kind{Type: &extensionsv1beta1.Ingress{}}, &handler.EnqueueRequestForObject{})
+	// Watch for changes to Ingress, falling back to extensions/v1beta1 on clusters without networking.k8s.io/v1
+	err = c.Watch(&source.Kind{Type: newWatchedIngress(!servesNetworkingV1Ingress(mgr.GetRESTMapper()))}, &handler.EnqueueRequestForObject{}, ingressInScope)
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to IngressClasses and their parameters, clusters before 1.19 only have the annotation
+	if servesIngressClass(mgr.GetRESTMapper()) {
+		err = c.Watch(&source.Kind{Type: &networkingv1.IngressClass{}}, handler.EnqueueRequestsFromMapFunc(r.mapIngressClassToIngresses))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Watch for changes to the controller defaults ConfigMap as well
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.mapIngressClassToIngresses))
 	if err != nil {
 		return err
 	}
 
-	// // TODO(user): Modify this to be the types you create
-	// // Uncomment watch a Deployment created by Ingress - change this for objects you create
-	// err = c.Watch(&source.Kind{Type: &appsv1.Deployment{}}, &handler.EnqueueRequestForOwner{
-	// 	IsController: true,
-	// 	OwnerType:    &extensionsv1beta1.Ingress{},
-	// })
-	// if err != nil {
-	// 	return err
-	// }
+	// Watch for changes to the APIGatewayConfigs referenced by ingresses
+	if servesAPIGatewayConfig(mgr.GetRESTMapper()) {
+		err = c.Watch(&source.Kind{Type: &v1alpha1.APIGatewayConfig{}}, handler.EnqueueRequestsFromMapFunc(r.mapAPIGatewayConfigToIngresses))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Watch for changes to TLS Secrets so renewed certificates are re-imported into ACM. Only their metadata is
+	// cached, the Secrets themselves are read from the API server, see main.
+	err = c.Watch(&source.Kind{Type: &metav1.PartialObjectMetadata{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}}}, handler.EnqueueRequestsFromMapFunc(r.mapSecretToIngresses))
+	if err != nil {
+		return err
+	}
+
+	// Watch the backend Services so the reverse proxy follows their ports
+	err = c.Watch(&source.Kind{Type: &corev1.Service{}}, handler.EnqueueRequestsFromMapFunc(r.mapServiceToIngresses))
+	if err != nil {
+		return err
+	}
+
+	// Watch Nodes so the target groups follow nodes joining and leaving
+	err = c.Watch(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(r.mapNodeToIngresses), nodeSetChanged)
+	if err != nil {
+		return err
+	}
+
+	// Watch the reverse proxy resources so edits and deletions are reverted
+	for _, owned := range []client.Object{&appsv1.Deployment{}, &corev1.Service{}, &corev1.ConfigMap{}} {
+		err = c.Watch(&source.Kind{Type: owned}, &handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    newWatchedIngress(r.legacyIngress),
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -190,11 +232,17 @@ type ReconcileIngress struct {
 	ec2Svc         ec2iface.EC2API
 	apigatewaySvc  apigatewayiface.APIGatewayAPI
 	autoscalingSvc autoscalingiface.AutoScalingAPI
+	elbv2Svc       elbv2iface.ELBV2API
+	acmSvc         acmiface.ACMAPI
+	acmEdgeSvc     acmiface.ACMAPI
 	s3Uploader     *s3manager.Uploader
+	sess           *session.Session
 	log            *zap.Logger
+	recorder       record.EventRecorder
+	legacyIngress  bool
 }
 
-func (r *ReconcileIngress) fetchNetworkingInfo(instance *extensionsv1beta1.Ingress) (*network.Network, error) {
+func (r *ReconcileIngress) fetchNetworkingInfo(instance *networkingv1.Ingress) (*network.Network, error) {
 	// TODO: We probably want to add some way of specifying which worker nodes we want to use. (security group ingress rules etc...)
 	r.log.Info("fetching worker nodes")
 	nodes := corev1.NodeList{
@@ -247,12 +295,17 @@ func (r *ReconcileIngress) fetchNetworkingInfo(instance *extensionsv1beta1.Ingre
 // +kubebuilder:rbac:groups=apps,resources=deployments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=nodes;services;configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=nodes/status;services/status;configmaps/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=extensions,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=extensions,resources=ingresses/status,verbs=get;update;patch
-func (r *ReconcileIngress) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingressclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apigateway.networking.amazonaws.com,resources=apigatewayconfigs,verbs=get;list;watch
+func (r *ReconcileIngress) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// Fetch the Ingress instance
-	instance := &extensionsv1beta1.Ingress{}
-	err := r.Get(context.TODO(), request.NamespacedName, instance)
+	instance, err := r.getIngress(request.NamespacedName)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
@@ -263,19 +316,48 @@ func (r *ReconcileIngress) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
-	// Ignore other ingress resources
-	if instance.Annotations[IngressClassAnnotation] != "apigateway" {
+	// Ignore ingresses outside of the namespaces and labels this controller serves
+	if !inScope(instance) {
 		return reconcile.Result{}, nil
 	}
 
-	if len(instance.GetObjectMeta().GetName()) > ingressNameLengthLimit {
-		return reconcile.Result{}, fmt.Errorf("ingress name must be < %d characters", ingressNameLengthLimit)
+	// Ignore ingress resources of other classes or controllers
+	class, handled, err := r.selectIngressClass(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !handled {
+		return reconcile.Result{}, nil
+	}
+
+	// Deletion goes ahead without the class defaults if they can't be read anymore
+	parameters, err := r.getIngressClassParameters(class)
+	if err != nil && instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, err
+	}
+	applyIngressClassParameters(instance, parameters)
+
+	// The controller defaults fill in what neither the ingress nor its class set
+	defaults, err := r.getControllerDefaults()
+	if err != nil && instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, err
+	}
+	applyControllerDefaults(instance, defaults)
+
+	// The referenced APIGatewayConfig replaces the JSON annotations, deletion doesn't need it
+	config, err := r.getAPIGatewayConfig(instance)
+	if err != nil && instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		r.log.Error("unable to fetch APIGatewayConfig", zap.String("name", instance.Annotations[IngressAnnotationConfig]), zap.Error(err))
+		return reconcile.Result{}, err
+	}
+	if err := applyAPIGatewayConfig(instance, config); err != nil {
+		return reconcile.Result{}, err
 	}
 
 	// Delete if timestamp is set
 	if instance.ObjectMeta.DeletionTimestamp.IsZero() == false {
 		if finalizers.HasFinalizer(instance, FinalizerCFNStack) || finalizers.HasFinalizer(instance, FinalizerRoute53CFNStack) {
-			// r.log.Info("deleting apigateway cloudformation stack", zap.String("stackName", instance.ObjectMeta.Name))
+			// r.log.Info("deleting apigateway cloudformation stack", zap.String("stackName", getStackName(instance)))
 			instance, requeue, err := r.delete(instance)
 			if requeue != nil {
 				return *requeue, nil
@@ -285,22 +367,60 @@ func (r *ReconcileIngress) Reconcile(request reconcile.Request) (reconcile.Resul
 				return reconcile.Result{}, err
 			}
 
-			return reconcile.Result{}, r.Update(context.TODO(), instance)
+			return reconcile.Result{}, r.updateIngress(instance)
 		}
 
 		return reconcile.Result{}, nil
 	}
 
+	// Malformed annotations would otherwise fall back to defaults and look like removed settings, so the stack is
+	// left alone until they are fixed. Fixing them updates the ingress and triggers another reconcile.
+	if errs := validateIngress(instance); len(errs) > 0 {
+		r.log.Error("invalid ingress, skipping until it is fixed", zap.String("name", instance.Name), zap.Error(errs.ToAggregate()))
+		r.event(instance, corev1.EventTypeWarning, EventReasonInvalidIngress, "%s", errs.ToAggregate().Error())
+		return reconcile.Result{}, nil
+	}
+
+	// Ingresses from before namespaced stack names adopt the stack named after them, deleted ingresses keep theirs
+	if err := r.recordStackName(instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Import the TLS Secrets, renewed certificates are re-imported under the same ARN
+	certificateArns, err := r.importTLSCertificates(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureFinalizer(instance, FinalizerCFNStack); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	// Check if stack exists
-	stack, err := cfn.DescribeStack(r.cfnSvc, instance.ObjectMeta.Name)
-	if err != nil && cfn.IsDoesNotExist(err, instance.ObjectMeta.Name) {
-		r.log.Info("creating apigateway", zap.String("stackName", instance.ObjectMeta.Name))
-		instance, err := r.create(instance)
+	stack, err := cfn.DescribeStack(r.cfnSvc, getStackName(instance))
+	if err != nil && cfn.IsDoesNotExist(err, getStackName(instance)) {
+		inputs, err := r.getStackInputs(instance, certificateArns)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if usesChangeSets(instance) {
+			return r.planStack(instance, nil, inputs)
+		}
+
+		r.log.Info("creating apigateway", zap.String("stackName", getStackName(instance)))
+		created, err := r.create(instance, inputs)
+		if cfn.IsAlreadyExists(err) {
+			// A previous leader created it in the meantime, it is picked up like any other stack
+			r.log.Info("stack already exists, requeuing", zap.String("stackName", getStackName(instance)))
+			return reconcile.Result{RequeueAfter: 20 * time.Second}, nil
+		}
 		if err != nil {
+			r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to create stack %s: %v", getStackName(instance), err)
 			return reconcile.Result{}, err
 		}
+		r.event(created, corev1.EventTypeNormal, EventReasonCreatingStack, "creating stack %s", getStackName(created))
 
-		if err := r.Update(context.TODO(), instance); err != nil {
+		if err := r.updateIngress(created); err != nil {
 			return reconcile.Result{}, err
 		}
 
@@ -310,55 +430,110 @@ func (r *ReconcileIngress) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
-	r.log.Info("Found Stack", zap.String("stackName", instance.ObjectMeta.Name), zap.String("StackStatus", *stack.StackStatus))
+	r.log.Info("Found Stack", zap.String("stackName", getStackName(instance)), zap.String("StackStatus", *stack.StackStatus))
+
+	// Planning a new ingress leaves a stack holding only change sets, it is deleted to create the real one
+	if *stack.StackStatus == cloudformation.StackStatusReviewInProgress {
+		if usesChangeSets(instance) {
+			inputs, err := r.getStackInputs(instance, certificateArns)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			return r.planStack(instance, stack, inputs)
+		}
+
+		if err := r.clearPlan(instance); err != nil {
+			return reconcile.Result{}, err
+		}
+		r.log.Info("deleting stack of dry run", zap.String("stackName", getStackName(instance)))
+		if _, err := r.cfnSvc.DeleteStack(&cloudformation.DeleteStackInput{StackName: stack.StackName}); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: 20 * time.Second}, nil
+	}
 
 	if cfn.IsFailed(*stack.StackStatus) {
-		return reconcile.Result{}, r.Update(context.TODO(), instance)
+		r.reportStackFailure(instance, r.cfnSvc, EventReasonStackFailed, getStackName(instance), *stack.StackStatus)
+		result, err := r.recoverStack(instance, r.cfnSvc, getStackName(instance), IngressAnnotationStackRecoveryState, stack)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if result != nil {
+			return *result, r.updateIngress(instance)
+		}
+		return reconcile.Result{}, r.updateIngress(instance)
 	}
 
 	if cfn.IsComplete(*stack.StackStatus) == false {
 		r.log.Info("Not complete, requeuing", zap.String("status", *stack.StackStatus))
 		// increasing timout value to 20 as create/update cf stack takes time and quick update gives errors sometimes
-		return reconcile.Result{RequeueAfter: 20 * time.Second}, r.Update(context.TODO(), instance)
+		return reconcile.Result{RequeueAfter: 20 * time.Second}, r.updateIngress(instance)
+	}
+
+	if err := r.resetStackRecovery(instance, IngressAnnotationStackRecoveryState); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Restore the reverse proxy, a recreated Service gets a NodePort the target group has to be moved to
+	inputs, err := r.getStackInputs(instance, certificateArns)
+	if err != nil {
+		r.log.Error("error fetching stack inputs", zap.String("stackName", getStackName(instance)), zap.Error(err))
+		return reconcile.Result{}, err
+	}
+
+	// Renders the template the stack settles with, an update that has to drop the WAF association first is
+	// followed by one restoring it
+	desired, err := buildStackTemplate(instance, inputs.network, int(inputs.proxy.Spec.Ports[0].NodePort), certificateArns, getWAFEnabled(instance))
+	if err != nil {
+		return reconcile.Result{}, err
 	}
 
-	if cfn.IsComplete(*stack.StackStatus) && shouldUpdate(stack, instance, r.apigatewaySvc, r) {
-		r.log.Info("updating apigateway cloudformation stack", zap.String("stackName", instance.ObjectMeta.Name))
-		if err := r.update(instance, stack); err != nil {
+	if cfn.IsComplete(*stack.StackStatus) && r.stackChanged(r.cfnSvc, stack, string(desired), stackTags(instance)) {
+		if usesChangeSets(instance) {
+			return r.planStack(instance, stack, inputs)
+		}
+
+		if err := r.clearPlan(instance); err != nil {
 			return reconcile.Result{}, err
 		}
 
+		r.log.Info("updating apigateway cloudformation stack", zap.String("stackName", getStackName(instance)))
+		if err := r.update(instance, stack, inputs); err != nil {
+			r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to update stack %s: %v", getStackName(instance), err)
+			return reconcile.Result{}, err
+		}
+		r.event(instance, corev1.EventTypeNormal, EventReasonUpdatingStack, "updating stack %s", getStackName(instance))
+
 		return reconcile.Result{Requeue: true}, nil
 	}
 
-	outputs := cfn.StackOutputMap(stack)
-
-	// Deploy API so changes are applied after Update
-	awsAPIConfigStr := cfn.StackOutputMap(stack)[cfn.OutputKeyAWSAPIConfigs]
-	var configArr []cfn.AWSAPIDefinition
-	if awsAPIConfigStr == "" {
-		configArr = []cfn.AWSAPIDefinition{cfn.AWSAPIDefinition{}}
-	} else {
-		err := json.Unmarshal([]byte(awsAPIConfigStr), &configArr)
-		if err != nil {
-			configArr = []cfn.AWSAPIDefinition{cfn.AWSAPIDefinition{}}
-		}
+	// Plans of changes that were reverted or applied are outdated
+	if err := r.clearPlan(instance); err != nil {
+		return reconcile.Result{}, err
 	}
-	apiSize := len(configArr)
 
-	for i := 0; i < apiSize; i++ {
-		time.Sleep(6000 * time.Millisecond)
-		r.log.Info("creating apigateway deployment", zap.String(fmt.Sprintf("%s%d", cfn.OutputKeyRestAPIID, i), outputs[fmt.Sprintf("%s%d", cfn.OutputKeyRestAPIID, i)]), zap.String("stage", getStageName(instance)))
-		if _, err := r.apigatewaySvc.CreateDeployment(&apigateway.CreateDeploymentInput{
-			RestApiId: aws.String(outputs[fmt.Sprintf("%s%d", cfn.OutputKeyRestAPIID, i)]),
-			StageName: aws.String(getStageName(instance)),
-		}); err != nil {
-			r.log.Error("unable to deploy ApiGateway Rest API", zap.Error(err))
+	// Once an update of the template finished, the stack no longer uses the certificates of TLS Secrets dropped
+	// from spec.tls. The deployments annotation still records the previous template until deployRestAPIs.
+	if getDeploymentState(instance).templateHash != stackTemplateHash(stack) {
+		if err := r.deleteUnusedCertificates(instance); err != nil {
 			return reconcile.Result{}, err
 		}
 	}
-	time.Sleep(6000 * time.Millisecond)
 
+	// Deploy the APIs so that changes of the update take effect, the remaining ones are deployed by requeues
+	result, err := r.deployRestAPIs(instance, stack)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if result != nil {
+		return *result, nil
+	}
+
+	if err := r.reconcileCanary(instance, stack); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	outputs := cfn.StackOutputMap(stack)
 	u, err := url.Parse(outputs[fmt.Sprintf("%s%d", cfn.OutputKeyAPIGatewayEndpoint, 0)])
 	if err != nil {
 		r.log.Error("unable to parse url from stack output", zap.Error(err), zap.String("output", fmt.Sprintf("%s%d", cfn.OutputKeyAPIGatewayEndpoint, 0)))
@@ -371,8 +546,13 @@ func (r *ReconcileIngress) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
+	if err := r.syncNodeTargets(instance, inputs.network); err != nil {
+		r.log.Error("unable to register nodes with the target group", zap.Error(err))
+		return reconcile.Result{}, err
+	}
+
 	r.log.Info("Stack Create/Update Complete")
-	instance.Status = extensionsv1beta1.IngressStatus{
+	instance.Status = networkingv1.IngressStatus{
 		LoadBalancer: corev1.LoadBalancerStatus{
 			Ingress: []corev1.LoadBalancerIngress{
 				corev1.LoadBalancerIngress{Hostname: u.Host},
@@ -384,8 +564,8 @@ func (r *ReconcileIngress) Reconcile(request reconcile.Request) (reconcile.Resul
 
 }
 
-func (r *ReconcileIngress) getASGsAndTargetGroup(instance *extensionsv1beta1.Ingress) ([]string, string, error) {
-	stackName := instance.ObjectMeta.Name
+func (r *ReconcileIngress) getASGsAndTargetGroup(instance *networkingv1.Ingress) ([]string, string, error) {
+	stackName := getStackName(instance)
 
 	network, err := r.fetchNetworkingInfo(instance)
 	if err != nil {
@@ -413,13 +593,13 @@ func (r *ReconcileIngress) getTargetGroupsFromASG(asgName string) ([]string, err
 	return aws.StringValueSlice(data.AutoScalingGroups[0].TargetGroupARNs), nil
 }
 
-func (r *ReconcileIngress) attachTGToASG(instance *extensionsv1beta1.Ingress) error {
+func (r *ReconcileIngress) attachTGToASG(instance *networkingv1.Ingress) error {
 	asgNames, targetGroupARN, err := r.getASGsAndTargetGroup(instance)
 	if err != nil {
 		return err
 	}
 
-	stackName := instance.ObjectMeta.Name
+	stackName := getStackName(instance)
 
 	for _, asgName := range asgNames {
 		existingTargetGroupARNs, err := r.getTargetGroupsFromASG(asgName)
@@ -446,13 +626,13 @@ func (r *ReconcileIngress) attachTGToASG(instance *extensionsv1beta1.Ingress) er
 	return nil
 }
 
-func (r *ReconcileIngress) detachTGFromASG(instance *extensionsv1beta1.Ingress) error {
+func (r *ReconcileIngress) detachTGFromASG(instance *networkingv1.Ingress) error {
 	asgNames, targetGroupARN, err := r.getASGsAndTargetGroup(instance)
 	if err != nil {
 		return err
 	}
 
-	stackName := instance.ObjectMeta.Name
+	stackName := getStackName(instance)
 
 	for _, asgName := range asgNames {
 		existingTargetGroupARNs, err := r.getTargetGroupsFromASG(asgName)
@@ -488,16 +668,19 @@ func contains(records []string, key string) bool {
 	return false
 }
 
-func (r *ReconcileIngress) delete(instance *extensionsv1beta1.Ingress) (*extensionsv1beta1.Ingress, *reconcile.Result, error) {
-	stack, err := cfn.DescribeStack(r.cfnSvc, instance.ObjectMeta.Name)
-	if err != nil && cfn.IsDoesNotExist(err, instance.ObjectMeta.Name) {
-		r.log.Info("stack doesn't exist, removing finalizer", zap.String("stackName", instance.ObjectMeta.Name))
+func (r *ReconcileIngress) delete(instance *networkingv1.Ingress) (*networkingv1.Ingress, *reconcile.Result, error) {
+	stack, err := cfn.DescribeStack(r.cfnSvc, getStackName(instance))
+	if err != nil && cfn.IsDoesNotExist(err, getStackName(instance)) {
+		r.log.Info("stack doesn't exist, removing finalizer", zap.String("stackName", getStackName(instance)))
+		if err := r.deleteUnusedCertificates(instance); err != nil {
+			return nil, nil, err
+		}
 		instance.SetFinalizers(finalizers.RemoveFinalizer(instance, FinalizerCFNStack))
 		return r.deleteRoute53(instance)
 	}
 
 	if err != nil {
-		r.log.Error("error describing apigateway cloudformation stack", zap.String("stackName", instance.ObjectMeta.Name), zap.Error(err))
+		r.log.Error("error describing apigateway cloudformation stack", zap.String("stackName", getStackName(instance)), zap.Error(err))
 		return nil, nil, err
 	}
 
@@ -507,35 +690,58 @@ func (r *ReconcileIngress) delete(instance *extensionsv1beta1.Ingress) (*extensi
 	}
 
 	if cfn.DeleteComplete(*stack.StackStatus) {
-		r.log.Info("delete complete, removing finalizer", zap.String("stackName", instance.ObjectMeta.Name))
+		r.log.Info("delete complete, removing finalizer", zap.String("stackName", getStackName(instance)))
+		if err := r.deleteUnusedCertificates(instance); err != nil {
+			return nil, nil, err
+		}
 		instance.SetFinalizers(finalizers.RemoveFinalizer(instance, FinalizerCFNStack))
 		return r.deleteRoute53(instance)
 	}
 
+	if cfn.IsFailed(*stack.StackStatus) {
+		r.reportStackFailure(instance, r.cfnSvc, EventReasonStackFailed, getStackName(instance), *stack.StackStatus)
+	}
+
+	// Resources that keep failing to delete are retained, plain retries take over once the attempts are used up
+	if *stack.StackStatus == cloudformation.StackStatusDeleteFailed {
+		result, err := r.recoverStack(instance, r.cfnSvc, getStackName(instance), IngressAnnotationStackRecoveryState, stack)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result != nil {
+			return instance, result, nil
+		}
+	}
+
 	// We want to retry delete even if DELETE_FAILED since removing Loadbalancer/VPCLink can be a bit finnicky
 	r.log.Info(
 		"deleting apigateway cloudformation stack",
-		zap.String("stackName", instance.ObjectMeta.Name),
+		zap.String("stackName", getStackName(instance)),
 		zap.String("status", *stack.StackStatus),
 	)
 
-	err = r.detachTGFromASG(instance)
-	if err != nil {
-		r.log.Error("unable to verify ASG before delete", zap.Error(err))
-		return nil, nil, err
+	// The stack of a dry run holds no resources yet
+	if *stack.StackStatus != cloudformation.StackStatusReviewInProgress {
+		err = r.detachTGFromASG(instance)
+		if err != nil {
+			r.log.Error("unable to verify ASG before delete", zap.Error(err))
+			return nil, nil, err
+		}
 	}
 
 	if _, err := r.cfnSvc.DeleteStack(&cloudformation.DeleteStackInput{
-		StackName: aws.String(instance.GetObjectMeta().GetName()),
+		StackName: aws.String(getStackName(instance)),
 	}); err != nil {
 		r.log.Error("error deleting apigateway cloudformation stack", zap.Error(err))
+		r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to delete stack %s: %v", getStackName(instance), err)
 		return nil, nil, err
 	}
+	r.event(instance, corev1.EventTypeNormal, EventReasonDeletingStack, "deleting stack %s", getStackName(instance))
 
 	return r.deleteRoute53(instance)
 }
 
-func (r *ReconcileIngress) buildReverseProxyResources(instance *extensionsv1beta1.Ingress) []metav1.Object {
+func (r *ReconcileIngress) buildReverseProxyResources(instance *networkingv1.Ingress, ports map[string]int32) []client.Object {
 	resourceName := createReverseProxyResourceName(instance.Name)
 
 	configMap := &corev1.ConfigMap{
@@ -548,7 +754,7 @@ func (r *ReconcileIngress) buildReverseProxyResources(instance *extensionsv1beta
 			Namespace: instance.Namespace,
 		},
 		Data: map[string]string{
-			"nginx.conf": buildNginxConfig(instance),
+			"nginx.conf": buildNginxConfig(instance, ports),
 		},
 	}
 
@@ -570,7 +776,11 @@ func (r *ReconcileIngress) buildReverseProxyResources(instance *extensionsv1beta
 				MatchLabels: map[string]string{"deployment": resourceName},
 			},
 			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"deployment": resourceName}},
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"deployment": resourceName},
+					// Rolls the pods when nginx.conf changes, nginx doesn't reload it by itself
+					Annotations: map[string]string{ReverseProxyConfigHashAnnotation: configHash(configMap.Data["nginx.conf"])},
+				},
 				Spec: corev1.PodSpec{
 					Volumes: []corev1.Volume{
 						corev1.Volume{
@@ -614,9 +824,10 @@ func (r *ReconcileIngress) buildReverseProxyResources(instance *extensionsv1beta
 		Spec: corev1.ServiceSpec{
 			Ports: []corev1.ServicePort{
 				corev1.ServicePort{
-					Name:     "http",
-					Protocol: "TCP",
-					Port:     int32(getNginxServicePort(instance)),
+					Name:       "http",
+					Protocol:   "TCP",
+					Port:       int32(getNginxServicePort(instance)),
+					TargetPort: intstr.FromInt(getNginxServicePort(instance)),
 				},
 			},
 			Selector:        map[string]string{"deployment": resourceName},
@@ -625,45 +836,43 @@ func (r *ReconcileIngress) buildReverseProxyResources(instance *extensionsv1beta
 		},
 	}
 
-	return []metav1.Object{configMap, deploy, service}
+	return []client.Object{configMap, deploy, service}
 }
 
-func (r *ReconcileIngress) updateReverseProxy(instance *extensionsv1beta1.Ingress) (*corev1.Service, error) {
-	objects := r.buildReverseProxyResources(instance)
+func (r *ReconcileIngress) updateReverseProxy(instance *networkingv1.Ingress) (*corev1.Service, error) {
+	ports, invalid, err := r.resolveBackendServicePorts(instance)
+	if err != nil {
+		r.log.Error("unable to resolve backend service ports", zap.Error(err))
+		return nil, err
+	}
+	if len(invalid) > 0 {
+		r.log.Info("backends not found, answering them with 503", zap.String("name", instance.Name), zap.Error(invalid.ToAggregate()))
+		r.event(instance, corev1.EventTypeWarning, EventReasonInvalidBackend, "%s", invalid.ToAggregate().Error())
+	}
+
+	// A dry run only plans, the reverse proxy is left as it is
+	dryRun := isDryRun(instance)
+	objects := r.buildReverseProxyResources(instance, ports)
 	for _, object := range objects {
-		if err := controllerutil.SetControllerReference(instance, object, r.scheme); err != nil {
+		if err := controllerutil.SetControllerReference(r.ingressOwner(instance), object, r.scheme); err != nil {
 			return nil, err
 		}
 
-		runtimeObject := object.(runtime.Object)
-
-		// Fix update issue on reverse proxy. Deleting current resource. Need to find reason for this
-		configMap := &corev1.ConfigMap{}
-		deployment := &appsv1.Deployment{}
-		if reflect.TypeOf(object) == reflect.TypeOf(configMap) || reflect.TypeOf(object) == reflect.TypeOf(deployment) {
-			r.log.Info("deleting reverse proxy resource config map", zap.String("gvk", runtimeObject.GetObjectKind().GroupVersionKind().String()), zap.String("name", object.GetName()))
-			r.Delete(context.TODO(), runtimeObject)
-			time.Sleep(2000 * time.Millisecond)
+		if dryRun {
+			continue
 		}
-
-		err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: object.GetName(), Namespace: object.GetNamespace()}, runtimeObject)
-		switch errors.IsNotFound(err) {
-		case true:
-			r.log.Info("creating reverse proxy resource", zap.String("gvk", runtimeObject.GetObjectKind().GroupVersionKind().String()), zap.String("name", object.GetName()))
-			if err := r.Create(context.TODO(), runtimeObject); err != nil {
-				return nil, err
-			}
-		case false:
-			r.log.Info("reverse proxy resource already exists, updating", zap.String("gvk", runtimeObject.GetObjectKind().GroupVersionKind().String()), zap.String("name", object.GetName()))
-			if err := r.Update(context.TODO(), runtimeObject); err != nil {
-				return nil, err
-			}
+		if err := r.applyReverseProxyResource(object); err != nil {
+			return nil, err
 		}
 	}
 
 	r.log.Info("fetching proxy service details")
 	svc := &corev1.Service{}
 	if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: createReverseProxyResourceName(instance.Name), Namespace: instance.Namespace}, svc); err != nil {
+		if dryRun && errors.IsNotFound(err) {
+			// The Service of a new ingress gets its NodePort once created
+			return objects[2].(*corev1.Service), nil
+		}
 		r.log.Error("unable to fetch proxy service", zap.Error(err))
 
 		return nil, err
@@ -672,232 +881,218 @@ func (r *ReconcileIngress) updateReverseProxy(instance *extensionsv1beta1.Ingres
 	return svc, nil
 }
 
-func (r *ReconcileIngress) create(instance *extensionsv1beta1.Ingress) (*extensionsv1beta1.Ingress, error) {
-	r.log.Info("creating reverse proxy")
-	svc, err := r.updateReverseProxy(instance)
-	if err != nil {
-		r.log.Error("error creating proxy resources", zap.Error(err))
-		return nil, err
+// applyReverseProxyResource creates the resource or restores the fields the controller manages when they drifted
+func (r *ReconcileIngress) applyReverseProxyResource(desired client.Object) error {
+	gvk := desired.GetObjectKind().GroupVersionKind().String()
+	existing := desired.DeepCopyObject().(client.Object)
+	err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, existing)
+	if errors.IsNotFound(err) {
+		r.log.Info("creating reverse proxy resource", zap.String("gvk", gvk), zap.String("name", desired.GetName()))
+		return r.Create(context.TODO(), desired)
 	}
-
-	// Fetch worker node networking info (grabs all nodes for now)
-	network, err := r.fetchNetworkingInfo(instance)
 	if err != nil {
-		r.log.Error("unable to fetch networking info", zap.Error(err))
-		return nil, err
+		return err
 	}
 
-	cfnTemplate := cfn.BuildAPIGatewayTemplateFromIngressRule(&cfn.TemplateConfig{
-		Rule:                   instance.Spec.Rules[0],
-		Network:                network,
-		NodePort:               int(svc.Spec.Ports[0].NodePort),
-		Arns:                   getArns(instance),
-		StageName:              getStageName(instance),
-		CustomDomainName:       getCustomDomainName(instance),
-		CustomDomainBasePath:   getCustomDomainBasePath(instance),
-		CertificateArn:         getCertificateArn(instance),
-		APIEndpointType:        getAPIEndpointType(instance),
-		WAFEnabled:             getWAFEnabled(instance),
-		WAFRulesJSON:           getWAFRulesJSON(instance),
-		WAFScope:               getWAFScope(instance),
-		WAFAssociation:         getWAFEnabled(instance),
-		RequestTimeout:         getRequestTimeout(instance),
-		TLSPolicy:              getTLSPolicy(instance),
-		UsagePlans:             getUsagePlans(instance),
-		MinimumCompressionSize: getCompressionSize(instance),
-		CachingEnabled:         getGWCacheEnabled(instance),
-		CachingSize:            getCacheSize(instance),
-		LoggingLevel:           getLoggingLevel(instance),
-		APIResources:           getAPIResources(instance),
-		AWSAPIDefinitions:      getAWSAPIConfigs(instance),
-	})
-
-	b, err := cfnTemplate.YAML()
-	if err != nil {
-		return nil, err
+	if !mergeReverseProxyResource(desired, existing) {
+		return nil
 	}
 
-	r.log.Info("creating cloudformation stack")
+	r.log.Info("reverse proxy resource drifted, updating", zap.String("gvk", gvk), zap.String("name", desired.GetName()))
+	return r.Update(context.TODO(), existing)
+}
 
-	bucketName := getS3BucketName(instance)
-	objectKey := getS3ObjectKey(instance)
-	if bucketName != "" && objectKey != "" {
-		templateBytes := []byte(string(b))
-		err := ioutil.WriteFile(fmt.Sprintf("/tmp/%s", objectKey), templateBytes, 0644)
-		if err != nil {
-			return nil, err
+// mergeReverseProxyResource copies the fields built by buildReverseProxyResources into existing and reports whether
+// any of them differed. Fields left unset in desired, and so defaulted by the apiserver, are not compared.
+func mergeReverseProxyResource(desired, existing client.Object) bool {
+	changed := false
+	if !equality.Semantic.DeepEqual(desired.GetOwnerReferences(), existing.GetOwnerReferences()) {
+		existing.SetOwnerReferences(desired.GetOwnerReferences())
+		changed = true
+	}
+
+	switch d := desired.(type) {
+	case *corev1.ConfigMap:
+		e := existing.(*corev1.ConfigMap)
+		if !equality.Semantic.DeepEqual(d.Data, e.Data) {
+			e.Data = d.Data
+			changed = true
 		}
-
-		file, err := os.Open(fmt.Sprintf("/tmp/%s", objectKey))
-		if err != nil {
-			return nil, err
+	case *appsv1.Deployment:
+		e := existing.(*appsv1.Deployment)
+		if !equality.Semantic.DeepDerivative(d.Spec, e.Spec) {
+			e.Spec = d.Spec
+			changed = true
 		}
-		defer file.Close()
-		_, err = r.s3Uploader.Upload(&s3manager.UploadInput{
-			Bucket: aws.String(bucketName),
-			Key:    aws.String(objectKey),
-			Body:   file,
-		})
-		if err != nil {
-			return nil, err
+	case *corev1.Service:
+		e := existing.(*corev1.Service)
+		// Keep the allocated NodePorts, the target group of the stack points at them
+		for i := range d.Spec.Ports {
+			for _, port := range e.Spec.Ports {
+				if port.Name == d.Spec.Ports[i].Name && d.Spec.Ports[i].NodePort == 0 {
+					d.Spec.Ports[i].NodePort = port.NodePort
+				}
+			}
 		}
-
-		if _, err := r.cfnSvc.CreateStack(&cloudformation.CreateStackInput{
-			TemplateURL:  aws.String(fmt.Sprintf("https://s3.amazonaws.com/%s/%s", bucketName, objectKey)),
-			StackName:    aws.String(instance.GetObjectMeta().GetName()),
-			Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
-			Tags: []*cloudformation.Tag{
-				{
-					Key:   aws.String("managedBy"),
-					Value: aws.String("amazon-apigateway-ingress-controller"),
-				},
-			},
-		}); err != nil {
-			return nil, err
-		}
-	} else {
-		if _, err := r.cfnSvc.CreateStack(&cloudformation.CreateStackInput{
-			TemplateBody: aws.String(string(b)),
-			StackName:    aws.String(instance.GetObjectMeta().GetName()),
-			Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
-			Tags: []*cloudformation.Tag{
-				{
-					Key:   aws.String("managedBy"),
-					Value: aws.String("amazon-apigateway-ingress-controller"),
-				},
-			},
-		}); err != nil {
-			return nil, err
+		if !equality.Semantic.DeepDerivative(d.Spec, e.Spec) {
+			e.Spec.Ports = d.Spec.Ports
+			e.Spec.Selector = d.Spec.Selector
+			e.Spec.SessionAffinity = d.Spec.SessionAffinity
+			e.Spec.Type = d.Spec.Type
+			changed = true
 		}
 	}
 
-	r.log.Info("cloudformation route53 stack creating, setting finalizers", zap.String("StackName", instance.ObjectMeta.Name))
+	return changed
+}
+
+func (r *ReconcileIngress) create(instance *networkingv1.Ingress, inputs *stackInputs) (*networkingv1.Ingress, error) {
+	template, err := r.renderStack(instance, nil, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	r.log.Info("creating cloudformation stack")
+	if _, err := r.cfnSvc.CreateStack(&cloudformation.CreateStackInput{
+		TemplateBody: template.templateBody(),
+		TemplateURL:  template.templateURL(),
+		StackName:    aws.String(getStackName(instance)),
+		Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
+		Tags:         withTemplateHash(template.body, stackTags(instance)),
+	}); err != nil {
+		return nil, err
+	}
+
+	r.log.Info("cloudformation route53 stack creating, setting finalizers", zap.String("StackName", getStackName(instance)))
 	instance.SetFinalizers(finalizers.AddFinalizer(instance, FinalizerCFNStack))
 
 	return instance, nil
 }
 
-func (r *ReconcileIngress) update(instance *extensionsv1beta1.Ingress, stack *cloudformation.Stack) error {
-	network, err := r.fetchNetworkingInfo(instance)
+func (r *ReconcileIngress) update(instance *networkingv1.Ingress, stack *cloudformation.Stack, inputs *stackInputs) error {
+	template, err := r.renderStack(instance, stack, inputs)
 	if err != nil {
-		r.log.Error("unable to fetch networking info", zap.Error(err))
 		return err
 	}
 
+	if _, err := r.cfnSvc.UpdateStack(&cloudformation.UpdateStackInput{
+		TemplateBody: template.templateBody(),
+		TemplateURL:  template.templateURL(),
+		StackName:    aws.String(getStackName(instance)),
+		Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
+		Tags:         withTemplateHash(template.body, stackTags(instance)),
+	}); err != nil {
+		r.log.Error("unable to update cloudformation stack", zap.String("stackName", getStackName(instance)), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// stackTemplate is the rendered template of the main stack, uploaded to S3 when the ingress names a bucket
+type stackTemplate struct {
+	body string
+	url  string
+}
+
+func (t *stackTemplate) templateBody() *string {
+	if t.url != "" {
+		return nil
+	}
+	return aws.String(t.body)
+}
+
+func (t *stackTemplate) templateURL() *string {
+	if t.url == "" {
+		return nil
+	}
+	return aws.String(t.url)
+}
+
+// stackInputs are what the template of the main stack is rendered from besides the ingress. They are gathered once
+// per reconcile.
+type stackInputs struct {
+	proxy           *corev1.Service
+	network         *network.Network
+	certificateArns map[string]string
+}
+
+// getStackInputs applies the reverse proxy and fetches the worker node network. In a dry run the reverse proxy
+// is not applied.
+func (r *ReconcileIngress) getStackInputs(instance *networkingv1.Ingress, certificateArns map[string]string) (*stackInputs, error) {
 	r.log.Info("updating proxy")
 	svc, err := r.updateReverseProxy(instance)
 	if err != nil {
 		r.log.Error("error creating proxy resources", zap.Error(err))
-		return err
+		return nil, err
+	}
+
+	// Fetch worker node networking info (grabs all nodes for now)
+	network, err := r.fetchNetworkingInfo(instance)
+	if err != nil {
+		r.log.Error("unable to fetch networking info", zap.Error(err))
+		return nil, err
 	}
 
+	return &stackInputs{proxy: svc, network: network, certificateArns: certificateArns}, nil
+}
+
+// renderStack renders the template of the main stack, stack is nil before it is created. In a dry run the template
+// is not uploaded.
+func (r *ReconcileIngress) renderStack(instance *networkingv1.Ingress, stack *cloudformation.Stack, inputs *stackInputs) (*stackTemplate, error) {
 	//With WAF enbled update gets a failure. To get rid of that do two updates to remove association and create it again
-	if getWAFEnabled(instance) {
-		r.log.Info("status waf association : ", zap.String("shouldUpdateWAF(stack)", fmt.Sprintf("%t", shouldUpdateWAF(stack))))
-	}
-
-	cfnTemplate := cfn.BuildAPIGatewayTemplateFromIngressRule(&cfn.TemplateConfig{
-		Rule:                   instance.Spec.Rules[0],
-		Network:                network,
-		Arns:                   getArns(instance),
-		StageName:              getStageName(instance),
-		NodePort:               int(svc.Spec.Ports[0].NodePort),
-		CustomDomainName:       getCustomDomainName(instance),
-		CustomDomainBasePath:   getCustomDomainBasePath(instance),
-		CertificateArn:         getCertificateArn(instance),
-		APIEndpointType:        getAPIEndpointType(instance),
-		WAFEnabled:             getWAFEnabled(instance),
-		WAFRulesJSON:           getWAFRulesJSON(instance),
-		WAFScope:               getWAFScope(instance),
-		WAFAssociation:         shouldUpdateWAF(stack),
-		RequestTimeout:         getRequestTimeout(instance),
-		TLSPolicy:              getTLSPolicy(instance),
-		UsagePlans:             getUsagePlans(instance),
-		MinimumCompressionSize: getCompressionSize(instance),
-		CachingEnabled:         getGWCacheEnabled(instance),
-		CachingSize:            getCacheSize(instance),
-		LoggingLevel:           getLoggingLevel(instance),
-		APIResources:           getAPIResources(instance),
-		AWSAPIDefinitions:      getAWSAPIConfigs(instance),
-	})
-	b, err := cfnTemplate.YAML()
+	wafAssociation := getWAFEnabled(instance)
+	if stack != nil {
+		wafAssociation = shouldUpdateWAF(stack)
+		if getWAFEnabled(instance) {
+			r.log.Info("status waf association : ", zap.String("shouldUpdateWAF(stack)", fmt.Sprintf("%t", wafAssociation)))
+		}
+	}
+
+	b, err := buildStackTemplate(instance, inputs.network, int(inputs.proxy.Spec.Ports[0].NodePort), inputs.certificateArns, wafAssociation)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	template := &stackTemplate{body: string(b)}
 
+	// A dry run plans with the template body rather than uploading it
 	bucketName := getS3BucketName(instance)
 	objectKey := getS3ObjectKey(instance)
-	if bucketName != "" && objectKey != "" {
-		templateBytes := []byte(string(b))
+	if bucketName != "" && objectKey != "" && !isDryRun(instance) {
+		templateBytes := []byte(template.body)
 		err := ioutil.WriteFile(fmt.Sprintf("/tmp/%s", objectKey), templateBytes, 0644)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		file, err := os.Open(fmt.Sprintf("/tmp/%s", objectKey))
 		if err != nil {
-			return err
+			return nil, err
 		}
 		defer file.Close()
-		_, err = r.s3Uploader.Upload(&s3manager.UploadInput{
+		out, err := r.s3Uploader.Upload(&s3manager.UploadInput{
 			Bucket: aws.String(bucketName),
 			Key:    aws.String(objectKey),
 			Body:   file,
 		})
 		if err != nil {
-			return err
-		}
-
-		if _, err := r.cfnSvc.UpdateStack(&cloudformation.UpdateStackInput{
-			TemplateURL:  aws.String(fmt.Sprintf("https://s3.amazonaws.com/%s/%s", bucketName, objectKey)),
-			StackName:    aws.String(instance.GetObjectMeta().GetName()),
-			Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
-			Tags: []*cloudformation.Tag{
-				{
-					Key:   aws.String("managedBy"),
-					Value: aws.String("aws-apigateway-ingress-controller"),
-				},
-			},
-		}); err != nil {
-			r.log.Error("unable to fetch proxy service", zap.Error(err))
-			return err
-		}
-	} else {
-		if _, err := r.cfnSvc.UpdateStack(&cloudformation.UpdateStackInput{
-			TemplateBody: aws.String(string(b)),
-			StackName:    aws.String(instance.GetObjectMeta().GetName()),
-			Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
-			Tags: []*cloudformation.Tag{
-				{
-					Key:   aws.String("managedBy"),
-					Value: aws.String("aws-apigateway-ingress-controller"),
-				},
-			},
-		}); err != nil {
-			r.log.Error("unable to fetch proxy service", zap.Error(err))
-			return err
+			return nil, err
 		}
+		template.url = out.Location
 	}
 
-	return nil
+	return template, nil
 }
 
-func (r *ReconcileIngress) createRoute53(instance *extensionsv1beta1.Ingress, mainStack *cloudformation.Stack) (*extensionsv1beta1.Ingress, error) {
-	stackName := fmt.Sprintf("%s%s", instance.ObjectMeta.Name, Route53StackNamePostfix)
+func (r *ReconcileIngress) createRoute53(instance *networkingv1.Ingress, mainStack *cloudformation.Stack) (*networkingv1.Ingress, error) {
+	stackName := getRoute53StackName(instance)
 
 	hostedZoneName := getHostedZoneName(instance)
 	if hostedZoneName == "" {
 		return instance, nil
 	}
 
-	cfnTemplate := cfn.BuildAPIGatewayRoute53Template(&cfn.Route53TemplateConfig{
-		CustomDomainName:         getCustomDomainName(instance),
-		HostedZoneName:           getHostedZoneName(instance),
-		CustomDomainHostName:     getCustomDomainCreatedHostname(mainStack),
-		CustomDomainHostedZoneID: getCustomDomainCreatedHostedZoneID(mainStack),
-	})
-
-	b, err := cfnTemplate.YAML()
+	b, err := buildRoute53Template(instance, mainStack)
 	if err != nil {
 		return nil, err
 	}
@@ -905,19 +1100,13 @@ func (r *ReconcileIngress) createRoute53(instance *extensionsv1beta1.Ingress, ma
 	route53AccountRole := getRoute53AccountRole(instance)
 
 	if route53AccountRole != "" {
-		sess, config := createAWSSharedAccountSession(r.log, route53AccountRole)
-		cfnClient := cloudformation.New(sess, config)
+		cfnClient := cloudformation.New(r.sess, sharedAccountConfig(r.sess, route53AccountRole))
 		r.log.Info("creating cloudformation stack")
 		if _, err := cfnClient.CreateStack(&cloudformation.CreateStackInput{
 			TemplateBody: aws.String(string(b)),
 			StackName:    aws.String(stackName),
 			Capabilities: aws.StringSlice([]string{"CAPABILITY_IAM"}),
-			Tags: []*cloudformation.Tag{
-				{
-					Key:   aws.String("managedBy"),
-					Value: aws.String("amazon-apigateway-ingress-controller"),
-				},
-			},
+			Tags:         withTemplateHash(string(b), stackTags(instance)),
 		}); err != nil {
 			return nil, err
 		}
@@ -927,12 +1116,7 @@ func (r *ReconcileIngress) createRoute53(instance *extensionsv1beta1.Ingress, ma
 			TemplateBody: aws.String(string(b)),
 			StackName:    aws.String(stackName),
 			Capabilities: aws.StringSlice([]string{"CAPABILITY_IAM"}),
-			Tags: []*cloudformation.Tag{
-				{
-					Key:   aws.String("managedBy"),
-					Value: aws.String("amazon-apigateway-ingress-controller"),
-				},
-			},
+			Tags:         withTemplateHash(string(b), stackTags(instance)),
 		}); err != nil {
 			return nil, err
 		}
@@ -944,21 +1128,15 @@ func (r *ReconcileIngress) createRoute53(instance *extensionsv1beta1.Ingress, ma
 	return instance, nil
 }
 
-func (r *ReconcileIngress) updateRoute53(instance *extensionsv1beta1.Ingress, mainStack *cloudformation.Stack) error {
-	stackName := fmt.Sprintf("%s%s", instance.ObjectMeta.Name, Route53StackNamePostfix)
+func (r *ReconcileIngress) updateRoute53(instance *networkingv1.Ingress, mainStack *cloudformation.Stack) error {
+	stackName := getRoute53StackName(instance)
 
 	hostedZoneName := getHostedZoneName(instance)
 	if hostedZoneName == "" {
 		return nil
 	}
 
-	cfnTemplate := cfn.BuildAPIGatewayRoute53Template(&cfn.Route53TemplateConfig{
-		CustomDomainName:         getCustomDomainName(instance),
-		HostedZoneName:           getHostedZoneName(instance),
-		CustomDomainHostName:     getCustomDomainCreatedHostname(mainStack),
-		CustomDomainHostedZoneID: getCustomDomainCreatedHostedZoneID(mainStack),
-	})
-	b, err := cfnTemplate.YAML()
+	b, err := buildRoute53Template(instance, mainStack)
 	if err != nil {
 		return err
 	}
@@ -966,18 +1144,12 @@ func (r *ReconcileIngress) updateRoute53(instance *extensionsv1beta1.Ingress, ma
 	route53AccountRole := getRoute53AccountRole(instance)
 
 	if route53AccountRole != "" {
-		sess, config := createAWSSharedAccountSession(r.log, route53AccountRole)
-		cfnClient := cloudformation.New(sess, config)
+		cfnClient := cloudformation.New(r.sess, sharedAccountConfig(r.sess, route53AccountRole))
 		if _, err := cfnClient.UpdateStack(&cloudformation.UpdateStackInput{
 			TemplateBody: aws.String(string(b)),
 			StackName:    aws.String(stackName),
 			Capabilities: aws.StringSlice([]string{"CAPABILITY_IAM"}),
-			Tags: []*cloudformation.Tag{
-				{
-					Key:   aws.String("managedBy"),
-					Value: aws.String("aws-apigateway-ingress-controller"),
-				},
-			},
+			Tags:         withTemplateHash(string(b), stackTags(instance)),
 		}); err != nil {
 			r.log.Error("Error wehen updating route53 cloudformation stack", zap.Error(err))
 			return err
@@ -987,12 +1159,7 @@ func (r *ReconcileIngress) updateRoute53(instance *extensionsv1beta1.Ingress, ma
 			TemplateBody: aws.String(string(b)),
 			StackName:    aws.String(stackName),
 			Capabilities: aws.StringSlice([]string{"CAPABILITY_IAM"}),
-			Tags: []*cloudformation.Tag{
-				{
-					Key:   aws.String("managedBy"),
-					Value: aws.String("aws-apigateway-ingress-controller"),
-				},
-			},
+			Tags:         withTemplateHash(string(b), stackTags(instance)),
 		}); err != nil {
 			r.log.Error("Error wehen updating route53 cloudformation stack", zap.Error(err))
 			return err
@@ -1002,14 +1169,13 @@ func (r *ReconcileIngress) updateRoute53(instance *extensionsv1beta1.Ingress, ma
 	return nil
 }
 
-func (r *ReconcileIngress) deleteRoute53(instance *extensionsv1beta1.Ingress) (*extensionsv1beta1.Ingress, *reconcile.Result, error) {
-	stackName := fmt.Sprintf("%s%s", instance.ObjectMeta.Name, Route53StackNamePostfix)
+func (r *ReconcileIngress) deleteRoute53(instance *networkingv1.Ingress) (*networkingv1.Ingress, *reconcile.Result, error) {
+	stackName := getRoute53StackName(instance)
 	route53AccountRole := getRoute53AccountRole(instance)
 	var stack *cloudformation.Stack
 	var err error
 	if route53AccountRole != "" {
-		sess, config := createAWSSharedAccountSession(r.log, route53AccountRole)
-		cfnClient := cloudformation.New(sess, config)
+		cfnClient := cloudformation.New(r.sess, sharedAccountConfig(r.sess, route53AccountRole))
 		stack, err = cfn.DescribeStack(cfnClient, stackName)
 	} else {
 		stack, err = cfn.DescribeStack(r.cfnSvc, stackName)
@@ -1036,6 +1202,20 @@ func (r *ReconcileIngress) deleteRoute53(instance *extensionsv1beta1.Ingress) (*
 		return instance, nil, nil
 	}
 
+	if cfn.IsFailed(*stack.StackStatus) {
+		r.reportStackFailure(instance, r.route53CFNClient(instance), EventReasonRoute53StackFailed, stackName, *stack.StackStatus)
+	}
+
+	if *stack.StackStatus == cloudformation.StackStatusDeleteFailed {
+		result, err := r.recoverStack(instance, r.route53CFNClient(instance), stackName, IngressAnnotationRoute53StackRecoveryState, stack)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result != nil {
+			return instance, result, nil
+		}
+	}
+
 	// We want to retry delete even if DELETE_FAILED since removing Loadbalancer/VPCLink can be a bit finnicky
 	r.log.Info(
 		"deleting apigateway route53 cloudformation stack",
@@ -1044,12 +1224,12 @@ func (r *ReconcileIngress) deleteRoute53(instance *extensionsv1beta1.Ingress) (*
 	)
 
 	if route53AccountRole != "" {
-		sess, config := createAWSSharedAccountSession(r.log, route53AccountRole)
-		cfnClient := cloudformation.New(sess, config)
+		cfnClient := cloudformation.New(r.sess, sharedAccountConfig(r.sess, route53AccountRole))
 		if _, err := cfnClient.DeleteStack(&cloudformation.DeleteStackInput{
 			StackName: aws.String(stackName),
 		}); err != nil {
 			r.log.Error("error deleting apigateway route53 cloudformation stack", zap.Error(err))
+			r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to delete route53 stack %s: %v", stackName, err)
 			return nil, nil, err
 		}
 	} else {
@@ -1057,22 +1237,28 @@ func (r *ReconcileIngress) deleteRoute53(instance *extensionsv1beta1.Ingress) (*
 			StackName: aws.String(stackName),
 		}); err != nil {
 			r.log.Error("error deleting apigateway route53 cloudformation stack", zap.Error(err))
+			r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to delete route53 stack %s: %v", stackName, err)
 			return nil, nil, err
 		}
 	}
+	r.event(instance, corev1.EventTypeNormal, EventReasonDeletingRoute53, "deleting route53 stack %s", stackName)
 
 	return instance, &reconcile.Result{Requeue: true}, nil
 }
 
-func (r *ReconcileIngress) reconcileRoute53(request reconcile.Request, mainStack *cloudformation.Stack, instance *extensionsv1beta1.Ingress) (reconcile.Result, error) {
-	stackName := fmt.Sprintf("%s%s", instance.ObjectMeta.Name, Route53StackNamePostfix)
+func (r *ReconcileIngress) reconcileRoute53(request reconcile.Request, mainStack *cloudformation.Stack, instance *networkingv1.Ingress) (reconcile.Result, error) {
+	stackName := getRoute53StackName(instance)
 
 	hostedZoneName := getHostedZoneName(instance)
 	r.log.Info("Reconile apigateway route53", zap.String("hostedZoneName", hostedZoneName))
 	if hostedZoneName == "" {
+		if finalizers.HasFinalizer(instance, FinalizerRoute53CFNStack) && isDryRun(instance) {
+			r.log.Info("dry run, not deleting route53 stack", zap.String("stackName", stackName))
+			return reconcile.Result{}, r.updateIngressStatus(instance)
+		}
 		if finalizers.HasFinalizer(instance, FinalizerRoute53CFNStack) {
 			r.log.Info("Ingress has finalizer, deleting.")
-			// r.log.Info("deleting apigateway cloudformation stack", zap.String("stackName", instance.ObjectMeta.Name))
+			// r.log.Info("deleting apigateway cloudformation stack", zap.String("stackName", getStackName(instance)))
 			instance, requeue, err := r.deleteRoute53(instance)
 			if requeue != nil {
 				return *requeue, nil
@@ -1080,31 +1266,44 @@ func (r *ReconcileIngress) reconcileRoute53(request reconcile.Request, mainStack
 			if err != nil {
 				return reconcile.Result{}, err
 			}
-			return reconcile.Result{}, r.Update(context.TODO(), instance)
+			return reconcile.Result{}, r.updateIngress(instance)
 		}
 		return reconcile.Result{}, nil
 	}
 
-	// Check if stack exists
+	if err := r.ensureFinalizer(instance, FinalizerRoute53CFNStack); err != nil {
+		return reconcile.Result{}, err
+	}
 
+	// Check if stack exists
 	route53AccountRole := getRoute53AccountRole(instance)
 	var stack *cloudformation.Stack
 	var err error
 	if route53AccountRole != "" {
-		sess, config := createAWSSharedAccountSession(r.log, route53AccountRole)
-		cfnClient := cloudformation.New(sess, config)
+		cfnClient := cloudformation.New(r.sess, sharedAccountConfig(r.sess, route53AccountRole))
 		stack, err = cfn.DescribeStack(cfnClient, stackName)
 	} else {
 		stack, err = cfn.DescribeStack(r.cfnSvc, stackName)
 	}
 	if err != nil && cfn.IsDoesNotExist(err, stackName) {
+		if isDryRun(instance) {
+			r.log.Info("dry run, not creating route53 stack", zap.String("stackName", stackName))
+			return reconcile.Result{}, r.updateIngressStatus(instance)
+		}
+
 		r.log.Info("creating apigateway route53", zap.String("stackName", stackName))
-		instance, err := r.createRoute53(instance, mainStack)
+		created, err := r.createRoute53(instance, mainStack)
+		if cfn.IsAlreadyExists(err) {
+			r.log.Info("route53 stack already exists, requeuing", zap.String("stackName", stackName))
+			return reconcile.Result{RequeueAfter: 20 * time.Second}, nil
+		}
 		if err != nil {
+			r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to create route53 stack %s: %v", stackName, err)
 			return reconcile.Result{}, err
 		}
+		r.event(created, corev1.EventTypeNormal, EventReasonCreatingRoute53, "creating route53 stack %s", stackName)
 
-		if err := r.Update(context.TODO(), instance); err != nil {
+		if err := r.updateIngress(created); err != nil {
 			return reconcile.Result{}, err
 		}
 
@@ -1117,52 +1316,62 @@ func (r *ReconcileIngress) reconcileRoute53(request reconcile.Request, mainStack
 	r.log.Info("Found Stack", zap.String("stackName", stackName), zap.String("StackStatus", *stack.StackStatus))
 
 	if cfn.IsFailed(*stack.StackStatus) {
-		return reconcile.Result{}, r.Update(context.TODO(), instance)
+		cfnClient := r.route53CFNClient(instance)
+		r.reportStackFailure(instance, cfnClient, EventReasonRoute53StackFailed, stackName, *stack.StackStatus)
+		result, err := r.recoverStack(instance, cfnClient, stackName, IngressAnnotationRoute53StackRecoveryState, stack)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if result != nil {
+			return *result, r.updateIngress(instance)
+		}
+		return reconcile.Result{}, r.updateIngress(instance)
 	}
 
 	if cfn.IsComplete(*stack.StackStatus) == false {
 		r.log.Info("Not complete, requeuing route53 stack", zap.String("status", *stack.StackStatus))
-		return reconcile.Result{RequeueAfter: 20 * time.Second}, r.Update(context.TODO(), instance)
+		return reconcile.Result{RequeueAfter: 20 * time.Second}, r.updateIngress(instance)
+	}
+
+	if err := r.resetStackRecovery(instance, IngressAnnotationRoute53StackRecoveryState); err != nil {
+		return reconcile.Result{}, err
 	}
 
-	if cfn.IsComplete(*stack.StackStatus) && shouldUpdateRoute53(mainStack, stack, instance) {
+	desired, err := buildRoute53Template(instance, mainStack)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if cfn.IsComplete(*stack.StackStatus) && r.stackChanged(r.route53CFNClient(instance), stack, string(desired), stackTags(instance)) {
+		if isDryRun(instance) {
+			r.log.Info("dry run, not updating route53 stack", zap.String("stackName", stackName))
+			return reconcile.Result{}, r.updateIngressStatus(instance)
+		}
+
 		r.log.Info("Updating apigateway route53 cloudformation stack", zap.String("stackName", stackName))
 		if err := r.updateRoute53(instance, mainStack); err != nil {
+			r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to update route53 stack %s: %v", stackName, err)
 			return reconcile.Result{}, err
 		}
+		r.event(instance, corev1.EventTypeNormal, EventReasonUpdatingRoute53, "updating route53 stack %s", stackName)
 		return reconcile.Result{Requeue: true}, nil
 	}
 	r.log.Info("Route53 Stack Create/Update Complete")
 
-	return reconcile.Result{}, r.Status().Update(context.TODO(), instance)
+	return reconcile.Result{}, r.updateIngressStatus(instance)
 
 }
 
-func createAWSSharedAccountSession(logger *zap.Logger, roleArn string) (*session.Session, *aws.Config) {
-	logger.Info("creating session for ec2metadata service")
-	sess, err := session.NewSession(&aws.Config{Region: aws.String("us-west-2")})
-	if err != nil {
-		logger.Fatal("unable create session for ec2 metadata service call", zap.Error(err))
-	}
-
-	ec2metadataSvc := ec2metadata.New(sess)
-	logger.Info("fetching ec2 identity document")
-	ec2IdentityDocument, err := ec2metadataSvc.GetInstanceIdentityDocument()
-	if err != nil {
-		logger.Fatal("unable to determine region from ec2", zap.Error(err))
-	}
-
-	logger.Info("creating AWS api session", zap.String("region", ec2IdentityDocument.Region))
-	sess = session.Must(session.NewSession())
-	creds := stscreds.NewCredentials(sess, roleArn)
-	config := &aws.Config{
-		Region:      aws.String(ec2IdentityDocument.Region),
-		Credentials: creds,
+// route53CFNClient returns the CloudFormation client of the account holding the route53 stack
+func (r *ReconcileIngress) route53CFNClient(instance *networkingv1.Ingress) cloudformationiface.CloudFormationAPI {
+	if route53AccountRole := getRoute53AccountRole(instance); route53AccountRole != "" {
+		return cloudformation.New(r.sess, sharedAccountConfig(r.sess, route53AccountRole))
 	}
 
-	if err != nil {
-		logger.Fatal("unable to create session for AWS services", zap.Error(err))
-	}
+	return r.cfnSvc
+}
 
-	return sess, config
+// sharedAccountConfig assumes the role of another account, in the region and with the endpoints of the session
+func sharedAccountConfig(sess *session.Session, roleArn string) *aws.Config {
+	return &aws.Config{Credentials: stscreds.NewCredentials(sess, roleArn)}
 }
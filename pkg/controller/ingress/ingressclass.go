@@ -0,0 +1,229 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	IngressClassDefaultAnnotation = "ingressclass.kubernetes.io/is-default-class"
+	IngressAnnotationPrefix       = "apigateway.ingress.kubernetes.io/"
+)
+
+var (
+	// ControllerName is matched against spec.controller of IngressClass objects
+	ControllerName = "apigateway.networking.amazonaws.com/ingress-controller"
+	// IngressClassName is the kubernetes.io/ingress.class annotation value handled when no IngressClass of that name exists
+	IngressClassName = "apigateway"
+	// IngressClassParametersNamespace is where the ConfigMaps referenced by IngressClass parameters live
+	IngressClassParametersNamespace = "default"
+)
+
+// servesIngressClass checks whether the apiserver serves networking.k8s.io/v1 IngressClasses
+func servesIngressClass(mapper meta.RESTMapper) bool {
+	_, err := mapper.RESTMapping(schema.GroupKind{Group: networkingv1.GroupName, Kind: "IngressClass"}, networkingv1.SchemeGroupVersion.Version)
+	return err == nil
+}
+
+func isMissing(err error) bool {
+	return errors.IsNotFound(err) || meta.IsNoMatchError(err)
+}
+
+func isDefaultIngressClass(class *networkingv1.IngressClass) bool {
+	return class.Annotations[IngressClassDefaultAnnotation] == "true"
+}
+
+// getIngressClass returns the IngressClass called name, or nil if it doesn't exist or the cluster has no IngressClasses
+func (r *ReconcileIngress) getIngressClass(name string) (*networkingv1.IngressClass, error) {
+	class := &networkingv1.IngressClass{}
+	if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: name}, class); err != nil {
+		if isMissing(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return class, nil
+}
+
+// getDefaultIngressClass returns the IngressClass marked as cluster default, or nil if there is none.
+// Multiple defaults are ambiguous, so they are treated like no default at all.
+func (r *ReconcileIngress) getDefaultIngressClass() (*networkingv1.IngressClass, error) {
+	classes := &networkingv1.IngressClassList{}
+	if err := r.List(context.TODO(), classes); err != nil {
+		if isMissing(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var found *networkingv1.IngressClass
+	for i := range classes.Items {
+		if !isDefaultIngressClass(&classes.Items[i]) {
+			continue
+		}
+		if found != nil {
+			return nil, nil
+		}
+		found = &classes.Items[i]
+	}
+
+	return found, nil
+}
+
+// isIngressClassParameters reports whether an IngressClass of this controller has the ConfigMap as parameters
+func (r *ReconcileIngress) isIngressClassParameters(name string) (bool, error) {
+	classes := &networkingv1.IngressClassList{}
+	if err := r.List(context.TODO(), classes); err != nil {
+		if isMissing(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, class := range classes.Items {
+		if class.Spec.Controller == ControllerName && class.Spec.Parameters != nil && class.Spec.Parameters.Name == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// selectIngressClass decides whether the ingress is handled by this controller and returns its IngressClass if it has one.
+// spec.ingressClassName takes precedence over the kubernetes.io/ingress.class annotation, ingresses with neither
+// belong to the cluster default class.
+func (r *ReconcileIngress) selectIngressClass(instance *networkingv1.Ingress) (*networkingv1.IngressClass, bool, error) {
+	if instance.Spec.IngressClassName != nil {
+		class, err := r.getIngressClass(*instance.Spec.IngressClassName)
+		if err != nil || class == nil {
+			return nil, false, err
+		}
+		return class, class.Spec.Controller == ControllerName, nil
+	}
+
+	if name, ok := instance.Annotations[IngressClassAnnotation]; ok {
+		class, err := r.getIngressClass(name)
+		if err != nil {
+			return nil, false, err
+		}
+		if class == nil {
+			return nil, name == IngressClassName, nil
+		}
+		return class, class.Spec.Controller == ControllerName, nil
+	}
+
+	class, err := r.getDefaultIngressClass()
+	if err != nil || class == nil {
+		return nil, false, err
+	}
+	return class, class.Spec.Controller == ControllerName, nil
+}
+
+// getIngressClassParameters returns the defaults of the ConfigMap referenced by the IngressClass parameters.
+// Keys are annotation names without the apigateway.ingress.kubernetes.io/ prefix.
+func (r *ReconcileIngress) getIngressClassParameters(class *networkingv1.IngressClass) (map[string]string, error) {
+	if class == nil || class.Spec.Parameters == nil {
+		return nil, nil
+	}
+
+	ref := class.Spec.Parameters
+	if (ref.APIGroup != nil && *ref.APIGroup != "") || ref.Kind != "ConfigMap" {
+		return nil, fmt.Errorf("unsupported parameters %s of IngressClass %s, only ConfigMaps are supported", ref.Kind, class.Name)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: ref.Name, Namespace: IngressClassParametersNamespace}, cm); err != nil {
+		return nil, err
+	}
+
+	return cm.Data, nil
+}
+
+// applyIngressClassParameters sets every annotation the ingress doesn't set itself to the class default.
+// This only changes the in-memory object, updateIngress never writes annotations back.
+func applyIngressClassParameters(instance *networkingv1.Ingress, parameters map[string]string) {
+	if len(parameters) == 0 {
+		return
+	}
+
+	if instance.Annotations == nil {
+		instance.Annotations = map[string]string{}
+	}
+
+	for k, v := range parameters {
+		key := IngressAnnotationPrefix + strings.TrimPrefix(k, IngressAnnotationPrefix)
+		if _, ok := instance.Annotations[key]; !ok {
+			instance.Annotations[key] = v
+		}
+	}
+}
+
+// ingressClassNameOf returns the class an ingress asks for, or "" if it belongs to the cluster default class
+func ingressClassNameOf(instance *networkingv1.Ingress) string {
+	if instance.Spec.IngressClassName != nil {
+		return *instance.Spec.IngressClassName
+	}
+
+	return instance.Annotations[IngressClassAnnotation]
+}
+
+// mapIngressClassToIngresses enqueues the ingresses affected by a change of an IngressClass, of a ConfigMap
+// referenced as IngressClass parameters or of the controller defaults ConfigMap, which affects all of them
+func (r *ReconcileIngress) mapIngressClassToIngresses(obj client.Object) []reconcile.Request {
+	isDefaults := DefaultsConfigMapName != "" && obj.GetName() == DefaultsConfigMapName && obj.GetNamespace() == IngressClassParametersNamespace
+
+	// Every ConfigMap of the cluster is watched, skip the unrelated ones before listing the ingresses
+	if cm, ok := obj.(*corev1.ConfigMap); ok && !isDefaults {
+		if cm.Namespace != IngressClassParametersNamespace {
+			return nil
+		}
+		referenced, err := r.isIngressClassParameters(cm.Name)
+		if err != nil {
+			r.log.Error("unable to list ingress classes", zap.Error(err))
+			return nil
+		}
+		if !referenced {
+			return nil
+		}
+	}
+
+	ingresses, err := r.listIngresses()
+	if err != nil {
+		r.log.Error("unable to list ingresses", zap.Error(err))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, instance := range ingresses {
+		switch o := obj.(type) {
+		case *networkingv1.IngressClass:
+			if name := ingressClassNameOf(instance); name != "" && name != o.Name {
+				continue
+			}
+		case *corev1.ConfigMap:
+			if isDefaults {
+				break
+			}
+			class, _, err := r.selectIngressClass(instance)
+			if err != nil || class == nil || class.Spec.Parameters == nil || class.Spec.Parameters.Name != o.Name {
+				continue
+			}
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}})
+	}
+
+	return requests
+}
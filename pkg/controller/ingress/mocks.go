@@ -2,10 +2,14 @@ package ingress
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/acm/acmiface"
 	"github.com/aws/aws-sdk-go/service/apigateway"
 	"github.com/aws/aws-sdk-go/service/apigateway/apigatewayiface"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
@@ -14,16 +18,71 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/finalizers"
 	corev1 "k8s.io/api/core/v1"
-	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+type mockACM struct {
+	acmiface.ACMAPI
+	Certificates map[string]*acm.ImportCertificateInput
+	InUse        map[string]bool
+}
+
+func (m *mockACM) ListTagsForCertificate(in *acm.ListTagsForCertificateInput) (*acm.ListTagsForCertificateOutput, error) {
+	imported, ok := m.Certificates[aws.StringValue(in.CertificateArn)]
+	if !ok {
+		return nil, awserr.New(acm.ErrCodeResourceNotFoundException, "certificate not found", fmt.Errorf(""))
+	}
+	if imported == nil {
+		return &acm.ListTagsForCertificateOutput{}, nil
+	}
+
+	return &acm.ListTagsForCertificateOutput{Tags: imported.Tags}, nil
+}
+
+func (m *mockACM) DeleteCertificate(in *acm.DeleteCertificateInput) (*acm.DeleteCertificateOutput, error) {
+	arn := aws.StringValue(in.CertificateArn)
+	if _, ok := m.Certificates[arn]; !ok {
+		return nil, awserr.New(acm.ErrCodeResourceNotFoundException, "certificate not found", fmt.Errorf(""))
+	}
+	if m.InUse[arn] {
+		return nil, awserr.New(acm.ErrCodeResourceInUseException, "certificate in use", fmt.Errorf(""))
+	}
+
+	delete(m.Certificates, arn)
+	return &acm.DeleteCertificateOutput{}, nil
+}
+
+func (m *mockACM) ImportCertificate(in *acm.ImportCertificateInput) (*acm.ImportCertificateOutput, error) {
+	arn := aws.StringValue(in.CertificateArn)
+	if arn == "" {
+		arn = fmt.Sprintf("arn:aws:acm:us-east-1:123456789012:certificate/%d", len(m.Certificates))
+	} else if _, ok := m.Certificates[arn]; !ok {
+		return nil, awserr.New(acm.ErrCodeResourceNotFoundException, "certificate not found", fmt.Errorf(""))
+	}
+
+	m.Certificates[arn] = in
+	return &acm.ImportCertificateOutput{CertificateArn: aws.String(arn)}, nil
+}
+
 type mockCloudformation struct {
 	cloudformationiface.CloudFormationAPI
-	Stacks map[string]*cloudformation.Stack
+	Stacks      map[string]*cloudformation.Stack
+	StackEvents map[string][]*cloudformation.StackEvent
+	Resources   map[string][]*cloudformation.StackResourceSummary
+	ChangeSets  map[string]*cloudformation.DescribeChangeSetOutput
+
+	DeleteStackInputs            []*cloudformation.DeleteStackInput
+	ContinueUpdateRollbackInputs []*cloudformation.ContinueUpdateRollbackInput
+	CreateChangeSetInputs        []*cloudformation.CreateChangeSetInput
+	DeleteChangeSetInputs        []*cloudformation.DeleteChangeSetInput
+	ExecuteChangeSetInputs       []*cloudformation.ExecuteChangeSetInput
+	CreateStackInputs            []*cloudformation.CreateStackInput
+	UpdateStackInputs            []*cloudformation.UpdateStackInput
 }
 
 func (m *mockCloudformation) CreateStack(in *cloudformation.CreateStackInput) (*cloudformation.CreateStackOutput, error) {
@@ -31,6 +90,7 @@ func (m *mockCloudformation) CreateStack(in *cloudformation.CreateStackInput) (*
 		return nil, fmt.Errorf("mockCloudformation.CreateStack failed")
 	}
 
+	m.CreateStackInputs = append(m.CreateStackInputs, in)
 	return &cloudformation.CreateStackOutput{}, nil
 }
 
@@ -38,9 +98,27 @@ func (m *mockCloudformation) UpdateStack(in *cloudformation.UpdateStackInput) (*
 	if *in.StackName == "brokenStackUpdate" {
 		return nil, fmt.Errorf("mockCloudformation.UpdateStack failed")
 	}
+
+	m.UpdateStackInputs = append(m.UpdateStackInputs, in)
 	return &cloudformation.UpdateStackOutput{}, nil
 }
 
+// GetTemplate returns the template the stack was last created or updated with
+func (m *mockCloudformation) GetTemplate(in *cloudformation.GetTemplateInput) (*cloudformation.GetTemplateOutput, error) {
+	for i := len(m.UpdateStackInputs) - 1; i >= 0; i-- {
+		if aws.StringValue(m.UpdateStackInputs[i].StackName) == aws.StringValue(in.StackName) {
+			return &cloudformation.GetTemplateOutput{TemplateBody: m.UpdateStackInputs[i].TemplateBody}, nil
+		}
+	}
+	for _, created := range m.CreateStackInputs {
+		if aws.StringValue(created.StackName) == aws.StringValue(in.StackName) {
+			return &cloudformation.GetTemplateOutput{TemplateBody: created.TemplateBody}, nil
+		}
+	}
+
+	return nil, awserr.New("ValidationError", fmt.Sprintf("Stack with id %s does not exist", aws.StringValue(in.StackName)), fmt.Errorf(""))
+}
+
 func (m *mockCloudformation) DescribeStacks(in *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
 	if *in.StackName == "broken" {
 		return nil, fmt.Errorf("mockCloudformation.DescribeStacks failed")
@@ -61,6 +139,7 @@ func (m *mockCloudformation) DeleteStack(in *cloudformation.DeleteStackInput) (*
 	}
 
 	if _, ok := m.Stacks[*in.StackName]; ok {
+		m.DeleteStackInputs = append(m.DeleteStackInputs, in)
 		delete(m.Stacks, *in.StackName)
 		return &cloudformation.DeleteStackOutput{}, nil
 	}
@@ -68,6 +147,91 @@ func (m *mockCloudformation) DeleteStack(in *cloudformation.DeleteStackInput) (*
 	return nil, awserr.New("ValidationError", fmt.Sprintf("Stack with id %s does not exist", *in.StackName), fmt.Errorf(""))
 }
 
+// CreateChangeSet records the change set, a CREATE change set creates the stack in REVIEW_IN_PROGRESS
+func (m *mockCloudformation) CreateChangeSet(in *cloudformation.CreateChangeSetInput) (*cloudformation.CreateChangeSetOutput, error) {
+	m.CreateChangeSetInputs = append(m.CreateChangeSetInputs, in)
+	if m.ChangeSets == nil {
+		m.ChangeSets = map[string]*cloudformation.DescribeChangeSetOutput{}
+	}
+	m.ChangeSets[*in.ChangeSetName] = &cloudformation.DescribeChangeSetOutput{
+		ChangeSetName: in.ChangeSetName,
+		StackName:     in.StackName,
+		Status:        aws.String(cloudformation.ChangeSetStatusCreatePending),
+	}
+	if _, ok := m.Stacks[*in.StackName]; !ok && aws.StringValue(in.ChangeSetType) == cloudformation.ChangeSetTypeCreate {
+		m.Stacks[*in.StackName] = &cloudformation.Stack{
+			StackName:   in.StackName,
+			StackStatus: aws.String(cloudformation.StackStatusReviewInProgress),
+		}
+	}
+
+	return &cloudformation.CreateChangeSetOutput{}, nil
+}
+
+func (m *mockCloudformation) DescribeChangeSet(in *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+	if changeSet, ok := m.ChangeSets[*in.ChangeSetName]; ok {
+		return changeSet, nil
+	}
+
+	return nil, awserr.New(cloudformation.ErrCodeChangeSetNotFoundException, fmt.Sprintf("ChangeSet [%s] does not exist", *in.ChangeSetName), fmt.Errorf(""))
+}
+
+func (m *mockCloudformation) ListChangeSetsPages(in *cloudformation.ListChangeSetsInput, fn func(*cloudformation.ListChangeSetsOutput, bool) bool) error {
+	var summaries []*cloudformation.ChangeSetSummary
+	for name, changeSet := range m.ChangeSets {
+		if aws.StringValue(changeSet.StackName) == *in.StackName {
+			summaries = append(summaries, &cloudformation.ChangeSetSummary{ChangeSetName: aws.String(name)})
+		}
+	}
+	fn(&cloudformation.ListChangeSetsOutput{Summaries: summaries}, true)
+	return nil
+}
+
+func (m *mockCloudformation) DeleteChangeSet(in *cloudformation.DeleteChangeSetInput) (*cloudformation.DeleteChangeSetOutput, error) {
+	m.DeleteChangeSetInputs = append(m.DeleteChangeSetInputs, in)
+	delete(m.ChangeSets, *in.ChangeSetName)
+	return &cloudformation.DeleteChangeSetOutput{}, nil
+}
+
+// ExecuteChangeSet starts the stack operation, CloudFormation deletes the other change sets of the stack
+func (m *mockCloudformation) ExecuteChangeSet(in *cloudformation.ExecuteChangeSetInput) (*cloudformation.ExecuteChangeSetOutput, error) {
+	changeSet, ok := m.ChangeSets[*in.ChangeSetName]
+	if !ok {
+		return nil, awserr.New(cloudformation.ErrCodeChangeSetNotFoundException, fmt.Sprintf("ChangeSet [%s] does not exist", *in.ChangeSetName), fmt.Errorf(""))
+	}
+
+	m.ExecuteChangeSetInputs = append(m.ExecuteChangeSetInputs, in)
+	if stack, ok := m.Stacks[*in.StackName]; ok {
+		status := cloudformation.StackStatusUpdateInProgress
+		if aws.StringValue(stack.StackStatus) == cloudformation.StackStatusReviewInProgress {
+			status = cloudformation.StackStatusCreateInProgress
+		}
+		stack.StackStatus = aws.String(status)
+	}
+	for name, other := range m.ChangeSets {
+		if aws.StringValue(other.StackName) == aws.StringValue(changeSet.StackName) {
+			delete(m.ChangeSets, name)
+		}
+	}
+
+	return &cloudformation.ExecuteChangeSetOutput{}, nil
+}
+
+func (m *mockCloudformation) DescribeStackEventsPages(in *cloudformation.DescribeStackEventsInput, fn func(*cloudformation.DescribeStackEventsOutput, bool) bool) error {
+	fn(&cloudformation.DescribeStackEventsOutput{StackEvents: m.StackEvents[*in.StackName]}, true)
+	return nil
+}
+
+func (m *mockCloudformation) ContinueUpdateRollback(in *cloudformation.ContinueUpdateRollbackInput) (*cloudformation.ContinueUpdateRollbackOutput, error) {
+	m.ContinueUpdateRollbackInputs = append(m.ContinueUpdateRollbackInputs, in)
+	return &cloudformation.ContinueUpdateRollbackOutput{}, nil
+}
+
+func (m *mockCloudformation) ListStackResourcesPages(in *cloudformation.ListStackResourcesInput, fn func(*cloudformation.ListStackResourcesOutput, bool) bool) error {
+	fn(&cloudformation.ListStackResourcesOutput{StackResourceSummaries: m.Resources[*in.StackName]}, true)
+	return nil
+}
+
 func (m *mockCloudformation) ListStackResources(in *cloudformation.ListStackResourcesInput) (*cloudformation.ListStackResourcesOutput, error) {
 
 	if _, ok := m.Stacks[*in.StackName]; ok {
@@ -86,7 +250,8 @@ func (m *mockCloudformation) ListStackResources(in *cloudformation.ListStackReso
 
 type mockEC2 struct {
 	ec2iface.EC2API
-	getASGTag bool
+	getASGTag              bool
+	describeInstancesCalls int
 }
 
 func (m *mockEC2) DescribeVpcs(in *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error) {
@@ -101,6 +266,7 @@ func (m *mockEC2) DescribeVpcs(in *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutp
 }
 
 func (m *mockEC2) DescribeInstances(in *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	m.describeInstancesCalls++
 	if m.getASGTag {
 		return &ec2.DescribeInstancesOutput{
 			Reservations: []*ec2.Reservation{
@@ -146,16 +312,131 @@ func (m *mockEC2) DescribeInstances(in *ec2.DescribeInstancesInput) (*ec2.Descri
 	}, nil
 }
 
+type mockELBV2 struct {
+	elbv2iface.ELBV2API
+	Targets map[string][]string
+}
+
+func (m *mockELBV2) DescribeTargetHealth(in *elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error) {
+	out := &elbv2.DescribeTargetHealthOutput{}
+	for _, id := range m.Targets[*in.TargetGroupArn] {
+		out.TargetHealthDescriptions = append(out.TargetHealthDescriptions, &elbv2.TargetHealthDescription{
+			Target: &elbv2.TargetDescription{Id: aws.String(id)},
+		})
+	}
+	return out, nil
+}
+
+func (m *mockELBV2) RegisterTargets(in *elbv2.RegisterTargetsInput) (*elbv2.RegisterTargetsOutput, error) {
+	for _, target := range in.Targets {
+		m.Targets[*in.TargetGroupArn] = append(m.Targets[*in.TargetGroupArn], *target.Id)
+	}
+	return &elbv2.RegisterTargetsOutput{}, nil
+}
+
+func (m *mockELBV2) DeregisterTargets(in *elbv2.DeregisterTargetsInput) (*elbv2.DeregisterTargetsOutput, error) {
+	var kept []string
+	for _, id := range m.Targets[*in.TargetGroupArn] {
+		deregistered := false
+		for _, target := range in.Targets {
+			deregistered = deregistered || *target.Id == id
+		}
+		if !deregistered {
+			kept = append(kept, id)
+		}
+	}
+	m.Targets[*in.TargetGroupArn] = kept
+	return &elbv2.DeregisterTargetsOutput{}, nil
+}
+
 type mockAPIGateway struct {
 	apigatewayiface.APIGatewayAPI
-	CreateDeploymentFail bool
+	CreateDeploymentFail     bool
+	CreateDeploymentThrottle bool
+	// Stages are the stages of the RestApis by RestApi id
+	Stages                 map[string]*apigateway.Stage
+	CreateDeploymentInputs []*apigateway.CreateDeploymentInput
+	UpdateStageInputs      []*apigateway.UpdateStageInput
+}
+
+func (m *mockAPIGateway) GetStage(in *apigateway.GetStageInput) (*apigateway.Stage, error) {
+	stage, ok := m.Stages[*in.RestApiId]
+	if !ok {
+		return nil, awserr.New(apigateway.ErrCodeNotFoundException, "Invalid stage identifier specified", nil)
+	}
+	out := *stage
+	return &out, nil
 }
 
 func (m *mockAPIGateway) CreateDeployment(in *apigateway.CreateDeploymentInput) (*apigateway.Deployment, error) {
 	if m.CreateDeploymentFail {
 		return nil, fmt.Errorf("mockAPIGateway.CreateDeployment failed")
 	}
-	return &apigateway.Deployment{}, nil
+	if m.CreateDeploymentThrottle {
+		return nil, awserr.New(apigateway.ErrCodeTooManyRequestsException, "Too Many Requests", nil)
+	}
+	m.CreateDeploymentInputs = append(m.CreateDeploymentInputs, in)
+	id := aws.String(fmt.Sprintf("deployment%d", len(m.CreateDeploymentInputs)))
+	if m.Stages == nil {
+		m.Stages = map[string]*apigateway.Stage{}
+	}
+	stage, ok := m.Stages[*in.RestApiId]
+	if !ok {
+		stage = &apigateway.Stage{StageName: in.StageName}
+		m.Stages[*in.RestApiId] = stage
+	}
+	if in.CanarySettings != nil {
+		stage.CanarySettings = &apigateway.CanarySettings{
+			DeploymentId:           id,
+			PercentTraffic:         in.CanarySettings.PercentTraffic,
+			StageVariableOverrides: in.CanarySettings.StageVariableOverrides,
+		}
+	} else {
+		stage.DeploymentId = id
+	}
+	return &apigateway.Deployment{Id: id}, nil
+}
+
+// UpdateStage applies the patch operations the controller uses for canaries
+func (m *mockAPIGateway) UpdateStage(in *apigateway.UpdateStageInput) (*apigateway.Stage, error) {
+	m.UpdateStageInputs = append(m.UpdateStageInputs, in)
+	stage, ok := m.Stages[*in.RestApiId]
+	if !ok {
+		return nil, awserr.New(apigateway.ErrCodeNotFoundException, "Invalid stage identifier specified", nil)
+	}
+	for _, op := range in.PatchOperations {
+		path, value := aws.StringValue(op.Path), op.Value
+		switch {
+		case path == "/deploymentId":
+			stage.DeploymentId = value
+		case path == "/canarySettings":
+			stage.CanarySettings = nil
+		case path == "/canarySettings/percentTraffic":
+			percent, err := strconv.ParseFloat(aws.StringValue(value), 64)
+			if err != nil {
+				return nil, err
+			}
+			stage.CanarySettings.PercentTraffic = aws.Float64(percent)
+		case strings.HasPrefix(path, "/canarySettings/stageVariableOverrides/"):
+			name := strings.TrimPrefix(path, "/canarySettings/stageVariableOverrides/")
+			if stage.CanarySettings.StageVariableOverrides == nil {
+				stage.CanarySettings.StageVariableOverrides = map[string]*string{}
+			}
+			if aws.StringValue(op.Op) == apigateway.OpRemove {
+				delete(stage.CanarySettings.StageVariableOverrides, name)
+			} else {
+				stage.CanarySettings.StageVariableOverrides[name] = value
+			}
+		case strings.HasPrefix(path, "/variables/"):
+			if stage.Variables == nil {
+				stage.Variables = map[string]*string{}
+			}
+			stage.Variables[strings.TrimPrefix(path, "/variables/")] = value
+		default:
+			return nil, fmt.Errorf("mockAPIGateway.UpdateStage: unsupported path %s", path)
+		}
+	}
+	return stage, nil
 }
 
 type mockAutoscaling struct {
@@ -164,6 +445,7 @@ type mockAutoscaling struct {
 	describeErr        bool
 	attachTGErr        bool
 	detachTGErr        bool
+	instanceIDs        []string
 }
 
 func (m *mockAutoscaling) DescribeAutoScalingGroups(in *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
@@ -182,12 +464,15 @@ func (m *mockAutoscaling) DescribeAutoScalingGroups(in *autoscaling.DescribeAuto
 		return nil, awserr.New("ValidationError", "cannot describe ASG", fmt.Errorf(""))
 	}
 
+	group := &autoscaling.Group{
+		VPCZoneIdentifier: aws.String("sub-foobar"),
+	}
+	for _, id := range m.instanceIDs {
+		group.Instances = append(group.Instances, &autoscaling.Instance{InstanceId: aws.String(id)})
+	}
+
 	return &autoscaling.DescribeAutoScalingGroupsOutput{
-		AutoScalingGroups: []*autoscaling.Group{
-			{
-				VPCZoneIdentifier: aws.String("sub-foobar"),
-			},
-		},
+		AutoScalingGroups: []*autoscaling.Group{group},
 	}, nil
 }
 
@@ -205,8 +490,8 @@ func (m *mockAutoscaling) DetachLoadBalancerTargetGroups(in *autoscaling.DetachL
 	return &autoscaling.DetachLoadBalancerTargetGroupsOutput{}, nil
 }
 
-func newMockIngress(name string, isDeleted, hasFinalizer bool) *extensionsv1beta1.Ingress {
-	instance := &extensionsv1beta1.Ingress{
+func newMockIngress(name string, isDeleted, hasFinalizer bool) *networkingv1.Ingress {
+	instance := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: "default",
@@ -217,17 +502,19 @@ func newMockIngress(name string, isDeleted, hasFinalizer bool) *extensionsv1beta
 				IngressAnnotationNginxImage: "nginx:latest",
 			},
 		},
-		Spec: extensionsv1beta1.IngressSpec{
-			Rules: []extensionsv1beta1.IngressRule{
-				extensionsv1beta1.IngressRule{
-					IngressRuleValue: extensionsv1beta1.IngressRuleValue{
-						HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
-							Paths: []extensionsv1beta1.HTTPIngressPath{
-								extensionsv1beta1.HTTPIngressPath{
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				networkingv1.IngressRule{
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								networkingv1.HTTPIngressPath{
 									Path: "/api/v1/foobar",
-									Backend: extensionsv1beta1.IngressBackend{
-										ServiceName: "foo",
-										ServicePort: intstr.FromInt(30123),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "foo",
+											Port: networkingv1.ServiceBackendPort{Number: 30123},
+										},
 									},
 								},
 							},
@@ -254,6 +541,15 @@ func newMockIngress(name string, isDeleted, hasFinalizer bool) *extensionsv1beta
 
 }
 
+// newMockStages returns stages serving the deployments, by RestApi id
+func newMockStages(deploymentIDs map[string]string) map[string]*apigateway.Stage {
+	stages := map[string]*apigateway.Stage{}
+	for id, deploymentID := range deploymentIDs {
+		stages[id] = &apigateway.Stage{DeploymentId: aws.String(deploymentID)}
+	}
+	return stages
+}
+
 func newMockService(name string) *corev1.Service {
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{Name: createReverseProxyResourceName(name), Namespace: "default"},
@@ -2,12 +2,19 @@ package ingress
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"html/template"
+	"strings"
 
-	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	networkingv1 "k8s.io/api/networking/v1"
 )
 
+// ReverseProxyConfigHashAnnotation on the reverse proxy pods holds the hash of their nginx.conf
+const ReverseProxyConfigHashAnnotation = "apigateway.ingress.kubernetes.io/config-hash"
+
 var nginxConfigTemplate = `
 worker_processes 1;
 
@@ -18,12 +25,16 @@ http {
 		client_max_body_size 100M;
 		server_tokens off;
 
+{{- range .HostRules }}
     server {
-      listen {{ .Port }};
-{{ range .Ingress.Spec.Rules -}}
-{{ range .IngressRuleValue.HTTP.Paths }}
-        location {{ .Path }} {
-          proxy_pass         http://{{ .Backend.ServiceName }}:{{ IntValue .Backend.ServicePort }};
+      listen {{ $.Port }}{{ if not .Host }} default_server{{ end }};
+{{- if .Host }}
+      server_name {{ .Host }};
+{{- end }}
+{{ range Locations .Paths }}
+        location {{ .Location }} {
+{{- with Backend .Backend }}
+          proxy_pass         http://{{ . }};
           proxy_redirect     off;
           proxy_set_header   Host $host;
           proxy_set_header   X-Real-IP $remote_addr;
@@ -32,17 +43,87 @@ http {
 				  proxy_http_version 1.1;
 				  proxy_set_header Connection "";
 				  proxy_ignore_client_abort on;
+{{- else }}
+          return 503;
+{{- end }}
        }
 {{ end }}
-{{- end }}
     }
+{{- end }}
 }
 `
 
-func buildNginxConfig(instance *extensionsv1beta1.Ingress) string {
+// nginxLocation is a location block of the reverse proxy and the backend it proxies to
+type nginxLocation struct {
+	Location string
+	Backend  networkingv1.IngressBackend
+}
+
+// nginxLocations renders the nginx locations of the paths of a rule according to their pathType. nginx prefers exact
+// locations, then the longest prefix, so that the most specific path wins regardless of the order of the paths, as
+// ingresses require. Prefix paths match on path elements, /foo as "= /foo" and "^~ /foo/", so that they match
+// /foo and /foo/bar but not /foobar, and no regex location is needed. A location that two paths render to is
+// served by the Exact one, or else the first.
+func nginxLocations(paths []networkingv1.HTTPIngressPath) []nginxLocation {
+	var locations []nginxLocation
+	seen := map[string]bool{}
+	add := func(key, location string, backend networkingv1.IngressBackend) {
+		if !seen[key] {
+			seen[key] = true
+			locations = append(locations, nginxLocation{Location: location, Backend: backend})
+		}
+	}
+
+	for _, path := range paths {
+		if path.PathType != nil && *path.PathType == networkingv1.PathTypeExact {
+			add("= "+path.Path, "= "+path.Path, path.Backend)
+		}
+	}
+	for _, path := range paths {
+		switch {
+		case path.PathType == nil || *path.PathType == networkingv1.PathTypeImplementationSpecific:
+			add(path.Path, path.Path, path.Backend)
+		case *path.PathType == networkingv1.PathTypePrefix:
+			prefix := strings.TrimSuffix(path.Path, "/")
+			if prefix == "" {
+				add("/", "/", path.Backend)
+				continue
+			}
+			add("= "+prefix, "= "+prefix, path.Backend)
+			add(prefix+"/", "^~ "+prefix+"/", path.Backend)
+		}
+	}
+
+	return locations
+}
+
+// backendServicePortKey is the key used to look up the resolved number of a named service port
+func backendServicePortKey(serviceName string, portName string) string {
+	return fmt.Sprintf("%s/%s", serviceName, portName)
+}
+
+// nginxBackend renders the host:port an ingress backend is proxied to, or nothing if resolveBackendServicePorts
+// didn't find its Service or port in ports
+func nginxBackend(backend networkingv1.IngressBackend, ports map[string]int32) string {
+	if backend.Service == nil {
+		return ""
+	}
+
+	port, ok := ports[backendKey(backend.Service)]
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d", backend.Service.Name, port)
+}
+
+// buildNginxConfig renders a server per ingress host, API Gateway sends the host of the rule as Host header.
+// Paths of rules without a host are served by the default server.
+func buildNginxConfig(instance *networkingv1.Ingress, ports map[string]int32) string {
 	t, err := template.New("").Funcs(template.FuncMap{
-		"IntValue": func(d intstr.IntOrString) int {
-			return d.IntValue()
+		"Locations": nginxLocations,
+		"Backend": func(backend networkingv1.IngressBackend) string {
+			return nginxBackend(backend, ports)
 		},
 	}).Parse(nginxConfigTemplate)
 	if err != nil {
@@ -51,14 +132,20 @@ func buildNginxConfig(instance *extensionsv1beta1.Ingress) string {
 
 	buf := bytes.NewBuffer([]byte{})
 	if err := t.Execute(buf, struct {
-		Ingress *extensionsv1beta1.Ingress
-		Port    int
+		HostRules []cfn.HostRule
+		Port      int
 	}{
-		Ingress: instance,
-		Port:    getNginxServicePort(instance),
+		HostRules: getHostRules(instance),
+		Port:      getNginxServicePort(instance),
 	}); err != nil {
 		panic(err)
 	}
 
 	return buf.String()
 }
+
+// configHash returns the hash of the nginx config, changing it in the pod template rolls the reverse proxy pods
+func configHash(config string) string {
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,161 @@
+package ingress
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/network"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// nodeSetChanged filters out Node status heartbeats, only nodes joining, leaving or changing the labels the
+// node-selector annotations match on change the targets of an ingress
+var nodeSetChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		newNode, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return false
+		}
+
+		return oldNode.Spec.ProviderID != newNode.Spec.ProviderID || !labels.Equals(oldNode.Labels, newNode.Labels)
+	},
+}
+
+// mapNodeToIngresses enqueues the ingresses whose node-selector, including the IngressClass and controller defaults, matches the node
+func (r *ReconcileIngress) mapNodeToIngresses(obj client.Object) []reconcile.Request {
+	ingresses, err := r.listIngresses()
+	if err != nil {
+		r.log.Error("unable to list ingresses", zap.Error(err))
+		return nil
+	}
+
+	defaults, err := r.getControllerDefaults()
+	if err != nil {
+		r.log.Error("unable to get controller defaults", zap.Error(err))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, instance := range ingresses {
+		class, handled, err := r.selectIngressClass(instance)
+		if err != nil || !handled {
+			continue
+		}
+		parameters, err := r.getIngressClassParameters(class)
+		if err != nil {
+			r.log.Error("unable to get ingress class parameters", zap.String("name", instance.Name), zap.Error(err))
+			continue
+		}
+		applyIngressClassParameters(instance, parameters)
+		applyControllerDefaults(instance, defaults)
+
+		if !getNodeSelector(instance).Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}})
+	}
+
+	return requests
+}
+
+// syncNodeTargets registers the selected nodes with the target group of the stack and deregisters those that left,
+// so that nodes outside an ASG and replaced nodes are served without a stack update. Instances of the ASGs the
+// target group is attached to are registered by the ASG and left alone.
+func (r *ReconcileIngress) syncNodeTargets(instance *networkingv1.Ingress, network *network.Network) error {
+	stackName := getStackName(instance)
+	targetGroupARN, err := cfn.GetResourceID(r.cfnSvc, stackName, cfn.TargetGroupResourceName)
+	if err != nil {
+		r.log.Error("error getting TargetGroupARN", zap.String("stackName", stackName))
+		return err
+	}
+
+	health, err := r.elbv2Svc.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
+		TargetGroupArn: aws.String(targetGroupARN),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to describe targets of %s: %v", targetGroupARN, err)
+	}
+
+	asgInstanceIDs, err := r.getASGInstanceIDs(network.ASGNames)
+	if err != nil {
+		return err
+	}
+
+	registered := map[string]bool{}
+	var deregister []*elbv2.TargetDescription
+	for _, target := range health.TargetHealthDescriptions {
+		id := aws.StringValue(target.Target.Id)
+		registered[id] = true
+		if !contains(network.InstanceIDs, id) && !asgInstanceIDs[id] {
+			deregister = append(deregister, &elbv2.TargetDescription{Id: aws.String(id)})
+		}
+	}
+
+	var register []*elbv2.TargetDescription
+	for _, id := range network.InstanceIDs {
+		if !registered[id] {
+			register = append(register, &elbv2.TargetDescription{Id: aws.String(id)})
+		}
+	}
+
+	if len(register) > 0 {
+		r.log.Info("registering nodes with target group", zap.String("stackName", stackName), zap.Int("count", len(register)))
+		if _, err := r.elbv2Svc.RegisterTargets(&elbv2.RegisterTargetsInput{
+			TargetGroupArn: aws.String(targetGroupARN),
+			Targets:        register,
+		}); err != nil {
+			return fmt.Errorf("unable to register targets with %s: %v", targetGroupARN, err)
+		}
+	}
+
+	if len(deregister) > 0 {
+		r.log.Info("deregistering nodes from target group", zap.String("stackName", stackName), zap.Int("count", len(deregister)))
+		if _, err := r.elbv2Svc.DeregisterTargets(&elbv2.DeregisterTargetsInput{
+			TargetGroupArn: aws.String(targetGroupARN),
+			Targets:        deregister,
+		}); err != nil {
+			return fmt.Errorf("unable to deregister targets from %s: %v", targetGroupARN, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileIngress) getASGInstanceIDs(asgNames []string) (map[string]bool, error) {
+	ids := map[string]bool{}
+	if len(asgNames) == 0 {
+		return ids, nil
+	}
+
+	output, err := r.autoscalingSvc.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: aws.StringSlice(asgNames),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe autoscaling groups %s: %v", strings.Join(asgNames, ","), err)
+	}
+
+	for _, group := range output.AutoScalingGroups {
+		for _, instance := range group.Instances {
+			ids[aws.StringValue(instance.InstanceId)] = true
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,329 @@
+package ingress
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// IngressAnnotationDryRun plans the changes to the stack of the ingress in a change set instead of applying them
+	IngressAnnotationDryRun = "apigateway.ingress.kubernetes.io/dry-run"
+	// IngressAnnotationRequireApproval plans the changes to the stack of the ingress in a change set that is only
+	// executed once approved
+	IngressAnnotationRequireApproval = "apigateway.ingress.kubernetes.io/require-approval"
+	// IngressAnnotationApprovedChangeSet approves the pending change set of that name, it is set by the approver
+	IngressAnnotationApprovedChangeSet = "apigateway.ingress.kubernetes.io/approved-change-set"
+	// IngressAnnotationPendingChangeSet records the change set waiting for approval, it is set by the controller
+	IngressAnnotationPendingChangeSet = "apigateway.ingress.kubernetes.io/pending-change-set"
+	// IngressAnnotationPlan records the changes planned by the last dry run, it is set by the controller
+	IngressAnnotationPlan = "apigateway.ingress.kubernetes.io/plan"
+
+	planChangeSetPrefix = "plan-"
+	planChangeSetHash   = 10
+
+	// noChangesReason is the status reason CloudFormation fails change sets without changes with
+	noChangesReason = "didn't contain changes"
+	noChanges       = "no changes"
+)
+
+// DryRun plans the changes to the stacks of all ingresses without applying them, it is set with --dry-run
+var DryRun = false
+
+// isDryRun tells if the stack changes of the ingress are planned rather than applied
+func isDryRun(instance *networkingv1.Ingress) bool {
+	if DryRun {
+		return true
+	}
+
+	dryRun, _ := parseBoolAnnotation(instance, IngressAnnotationDryRun)
+	return dryRun
+}
+
+// requiresApproval tells if the stack changes of the ingress wait for approval, a dry run doesn't apply them at all
+func requiresApproval(instance *networkingv1.Ingress) bool {
+	requireApproval, _ := parseBoolAnnotation(instance, IngressAnnotationRequireApproval)
+	return requireApproval
+}
+
+// usesChangeSets tells if the changes to the main stack of the ingress go through change sets rather than
+// CreateStack and UpdateStack
+func usesChangeSets(instance *networkingv1.Ingress) bool {
+	return isDryRun(instance) || requiresApproval(instance)
+}
+
+// planStack plans the changes to the main stack of an ingress using change sets
+func (r *ReconcileIngress) planStack(instance *networkingv1.Ingress, stack *cloudformation.Stack, inputs *stackInputs) (reconcile.Result, error) {
+	if isDryRun(instance) {
+		return r.plan(instance, stack, inputs)
+	}
+
+	return r.awaitApproval(instance, stack, inputs)
+}
+
+// planChangeSetName names the change set after the template and its type, so that every reconcile of an unchanged
+// ingress finds the change set of the last one. Updates are named after the version of the stack they change as
+// well, so that an approval never applies to a later update with the same template.
+func planChangeSetName(template *stackTemplate, changeSetType string, stack *cloudformation.Stack) string {
+	version := ""
+	if changeSetType == cloudformation.ChangeSetTypeUpdate {
+		version = aws.TimeValue(stack.CreationTime).String()
+		if stack.LastUpdatedTime != nil {
+			version = aws.TimeValue(stack.LastUpdatedTime).String()
+		}
+	}
+
+	sum := sha256.Sum256([]byte(changeSetType + "\n" + version + "\n" + template.url + "\n" + template.body))
+	return fmt.Sprintf("%s%x", planChangeSetPrefix, sum)[:len(planChangeSetPrefix)+planChangeSetHash]
+}
+
+// plan renders the template of the main stack into a change set and records its summary on the ingress, without
+// executing it
+func (r *ReconcileIngress) plan(instance *networkingv1.Ingress, stack *cloudformation.Stack, inputs *stackInputs) (reconcile.Result, error) {
+	changeSet, summary, result, err := r.prepareChangeSet(instance, stack, inputs)
+	if result != nil || err != nil {
+		return *result, err
+	}
+
+	return reconcile.Result{}, r.recordPlan(instance, changeSet, summary, "")
+}
+
+// awaitApproval prepares the change set of the main stack like a dry run and executes it once the approved-change-set
+// annotation names it. A change set of an outdated template is deleted when the next one is prepared.
+func (r *ReconcileIngress) awaitApproval(instance *networkingv1.Ingress, stack *cloudformation.Stack, inputs *stackInputs) (reconcile.Result, error) {
+	changeSet, summary, result, err := r.prepareChangeSet(instance, stack, inputs)
+	if result != nil || err != nil {
+		return *result, err
+	}
+
+	name := aws.StringValue(changeSet.ChangeSetName)
+	if aws.StringValue(changeSet.ExecutionStatus) != cloudformation.ExecutionStatusAvailable {
+		return reconcile.Result{}, r.recordPlan(instance, changeSet, summary, "")
+	}
+
+	if instance.Annotations[IngressAnnotationApprovedChangeSet] != name {
+		return reconcile.Result{}, r.recordPlan(instance, changeSet, summary, name)
+	}
+
+	stackName := getStackName(instance)
+	r.log.Info("executing approved change set", zap.String("stackName", stackName), zap.String("changeSetName", name))
+	if _, err := r.cfnSvc.ExecuteChangeSet(&cloudformation.ExecuteChangeSetInput{
+		StackName:     aws.String(stackName),
+		ChangeSetName: aws.String(name),
+	}); err != nil {
+		r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to execute change set %s of stack %s: %v", name, stackName, err)
+		return reconcile.Result{}, err
+	}
+	r.event(instance, corev1.EventTypeNormal, EventReasonExecutingChangeSet, "executing approved change set %s of stack %s", name, stackName)
+
+	if err := r.updateIngressAnnotation(instance, IngressAnnotationPendingChangeSet, ""); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{Requeue: true}, r.updateIngressAnnotation(instance, IngressAnnotationPlan, "")
+}
+
+// prepareChangeSet renders the template of the main stack into a change set and returns it with its summary once
+// CloudFormation has computed it, or the result to requeue with until then. stack is nil, or in REVIEW_IN_PROGRESS
+// after a previous plan, when the stack wasn't created yet.
+func (r *ReconcileIngress) prepareChangeSet(instance *networkingv1.Ingress, stack *cloudformation.Stack, inputs *stackInputs) (*cloudformation.DescribeChangeSetOutput, string, *reconcile.Result, error) {
+	stackName := getStackName(instance)
+	changeSetType := cloudformation.ChangeSetTypeUpdate
+	if stack == nil || aws.StringValue(stack.StackStatus) == cloudformation.StackStatusReviewInProgress {
+		changeSetType = cloudformation.ChangeSetTypeCreate
+	}
+
+	renderFrom := stack
+	if changeSetType == cloudformation.ChangeSetTypeCreate {
+		renderFrom = nil
+	}
+	template, err := r.renderStack(instance, renderFrom, inputs)
+	if err != nil {
+		return nil, "", &reconcile.Result{}, err
+	}
+	changeSetName := planChangeSetName(template, changeSetType, stack)
+
+	var changeSet *cloudformation.DescribeChangeSetOutput
+	if stack != nil {
+		changeSet, err = describeChangeSet(r.cfnSvc, stackName, changeSetName)
+		if err != nil && !cfn.IsChangeSetNotFound(err) {
+			return nil, "", &reconcile.Result{}, err
+		}
+	}
+
+	if changeSet == nil {
+		if stack != nil {
+			if err := r.deletePlans(stackName); err != nil {
+				return nil, "", &reconcile.Result{}, err
+			}
+		}
+
+		r.log.Info("creating change set", zap.String("stackName", stackName), zap.String("changeSetName", changeSetName))
+		if _, err := r.cfnSvc.CreateChangeSet(&cloudformation.CreateChangeSetInput{
+			ChangeSetName: aws.String(changeSetName),
+			ChangeSetType: aws.String(changeSetType),
+			Description:   aws.String(fmt.Sprintf("changes to ingress %s/%s", instance.Namespace, instance.Name)),
+			TemplateBody:  template.templateBody(),
+			TemplateURL:   template.templateURL(),
+			StackName:     aws.String(stackName),
+			Capabilities:  aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
+			Tags:          withTemplateHash(template.body, stackTags(instance)),
+		}); err != nil {
+			r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to plan stack %s: %v", stackName, err)
+			return nil, "", &reconcile.Result{}, err
+		}
+
+		return nil, "", &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	switch status := aws.StringValue(changeSet.Status); status {
+	case cloudformation.ChangeSetStatusCreateComplete:
+		return changeSet, summarizeChanges(changeSet.Changes), nil, nil
+	case cloudformation.ChangeSetStatusFailed:
+		reason := aws.StringValue(changeSet.StatusReason)
+		if strings.Contains(reason, noChangesReason) {
+			return changeSet, noChanges, nil, nil
+		}
+		return changeSet, "failed: " + reason, nil, nil
+	default:
+		r.log.Info("change set not complete, requeuing", zap.String("changeSetName", changeSetName), zap.String("status", status))
+		return nil, "", &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+}
+
+// recordPlan records the summary of the change set on the ingress and the change set waiting for approval, if any
+func (r *ReconcileIngress) recordPlan(instance *networkingv1.Ingress, changeSet *cloudformation.DescribeChangeSetOutput, summary string, pending string) error {
+	stackName := getStackName(instance)
+	name := aws.StringValue(changeSet.ChangeSetName)
+	plan := fmt.Sprintf("%s: %s", name, summary)
+	if instance.Annotations[IngressAnnotationPlan] == plan && instance.Annotations[IngressAnnotationPendingChangeSet] == pending {
+		return nil
+	}
+
+	eventType := corev1.EventTypeNormal
+	if aws.StringValue(changeSet.Status) == cloudformation.ChangeSetStatusFailed && summary != noChanges {
+		eventType = corev1.EventTypeWarning
+	}
+	r.log.Info("planned stack changes", zap.String("stackName", stackName), zap.String("plan", plan))
+	if pending != "" {
+		r.event(instance, eventType, EventReasonAwaitingApproval, "change set %s of stack %s awaits approval: %s", name, stackName, summary)
+	} else {
+		r.event(instance, eventType, EventReasonPlannedChanges, "planned changes to stack %s in change set %s", stackName, plan)
+	}
+
+	if err := r.updateIngressAnnotation(instance, IngressAnnotationPendingChangeSet, pending); err != nil {
+		return err
+	}
+	return r.updateIngressAnnotation(instance, IngressAnnotationPlan, plan)
+}
+
+// clearPlan removes the plan and the pending change set once the stack needs no changes or they are applied
+// without a plan
+func (r *ReconcileIngress) clearPlan(instance *networkingv1.Ingress) error {
+	if instance.Annotations[IngressAnnotationPlan] == "" && instance.Annotations[IngressAnnotationPendingChangeSet] == "" {
+		return nil
+	}
+
+	if err := r.deletePlans(getStackName(instance)); err != nil {
+		return err
+	}
+
+	if err := r.updateIngressAnnotation(instance, IngressAnnotationPendingChangeSet, ""); err != nil {
+		return err
+	}
+	return r.updateIngressAnnotation(instance, IngressAnnotationPlan, "")
+}
+
+// deletePlans deletes the change sets of previous dry runs, CloudFormation would keep them with the stack
+func (r *ReconcileIngress) deletePlans(stackName string) error {
+	var names []string
+	err := r.cfnSvc.ListChangeSetsPages(&cloudformation.ListChangeSetsInput{StackName: aws.String(stackName)}, func(page *cloudformation.ListChangeSetsOutput, lastPage bool) bool {
+		for _, summary := range page.Summaries {
+			if name := aws.StringValue(summary.ChangeSetName); strings.HasPrefix(name, planChangeSetPrefix) {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		if cfn.IsDoesNotExist(err, stackName) {
+			return nil
+		}
+		return err
+	}
+
+	for _, name := range names {
+		r.log.Info("deleting change set", zap.String("stackName", stackName), zap.String("changeSetName", name))
+		if _, err := r.cfnSvc.DeleteChangeSet(&cloudformation.DeleteChangeSetInput{
+			StackName:     aws.String(stackName),
+			ChangeSetName: aws.String(name),
+		}); err != nil && !cfn.IsChangeSetNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// describeChangeSet returns the change set with the changes of all pages
+func describeChangeSet(cfnSvc cloudformationiface.CloudFormationAPI, stackName string, changeSetName string) (*cloudformation.DescribeChangeSetOutput, error) {
+	input := &cloudformation.DescribeChangeSetInput{
+		StackName:     aws.String(stackName),
+		ChangeSetName: aws.String(changeSetName),
+	}
+
+	var changeSet *cloudformation.DescribeChangeSetOutput
+	for {
+		out, err := cfnSvc.DescribeChangeSet(input)
+		if err != nil {
+			return nil, err
+		}
+		if changeSet == nil {
+			changeSet = out
+		} else {
+			changeSet.Changes = append(changeSet.Changes, out.Changes...)
+		}
+		if aws.StringValue(out.NextToken) == "" {
+			return changeSet, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// summarizeChanges lists the resource changes as "<action> <logical id> (<type>)", modifications replacing the
+// resource are listed as replace
+func summarizeChanges(changes []*cloudformation.Change) string {
+	var summaries []string
+	for _, change := range changes {
+		rc := change.ResourceChange
+		if rc == nil {
+			continue
+		}
+
+		action := strings.ToLower(aws.StringValue(rc.Action))
+		if aws.StringValue(rc.Action) == cloudformation.ChangeActionModify {
+			switch aws.StringValue(rc.Replacement) {
+			case cloudformation.ReplacementTrue:
+				action = "replace"
+			case cloudformation.ReplacementConditional:
+				action = "modify (may replace)"
+			}
+		}
+		summaries = append(summaries, fmt.Sprintf("%s %s (%s)", action, aws.StringValue(rc.LogicalResourceId), aws.StringValue(rc.ResourceType)))
+	}
+
+	if len(summaries) == 0 {
+		return noChanges
+	}
+
+	return strings.Join(summaries, ", ")
+}
@@ -0,0 +1,185 @@
+package ingress
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	IngressAnnotationStackRecoveryPolicy       = "apigateway.ingress.kubernetes.io/stack-recovery-policy"
+	IngressAnnotationStackRecoveryMaxRetries   = "apigateway.ingress.kubernetes.io/stack-recovery-max-retries"
+	IngressAnnotationStackRecoveryState        = "apigateway.ingress.kubernetes.io/stack-recovery-state"
+	IngressAnnotationRoute53StackRecoveryState = "apigateway.ingress.kubernetes.io/route53-stack-recovery-state"
+
+	// StackRecoveryPolicyNone leaves failed stacks alone
+	StackRecoveryPolicyNone = "None"
+	// StackRecoveryPolicyAutomatic repairs ROLLBACK_COMPLETE, UPDATE_ROLLBACK_FAILED and DELETE_FAILED stacks
+	StackRecoveryPolicyAutomatic = "Automatic"
+
+	DefaultStackRecoveryMaxRetries = 5
+)
+
+var (
+	supportedStackRecoveryPolicies = []string{StackRecoveryPolicyNone, StackRecoveryPolicyAutomatic}
+
+	// stackRecoveryBackoff is the wait before the second attempt, it doubles with every further attempt
+	stackRecoveryBackoff    = 30 * time.Second
+	stackRecoveryMaxBackoff = 30 * time.Minute
+)
+
+// stackRecoveryState is persisted on the ingress as "<attempts>,<RFC3339 time of the last attempt>" so that the
+// retries survive controller restarts
+type stackRecoveryState struct {
+	attempts int
+	last     time.Time
+}
+
+func getStackRecoveryPolicy(instance *networkingv1.Ingress) string {
+	v, _ := parseEnumAnnotation(instance, IngressAnnotationStackRecoveryPolicy, StackRecoveryPolicyNone, supportedStackRecoveryPolicies)
+	return v
+}
+
+func getStackRecoveryMaxRetries(instance *networkingv1.Ingress) int {
+	v, _ := parseIntAnnotation(instance, IngressAnnotationStackRecoveryMaxRetries, DefaultStackRecoveryMaxRetries)
+	return v
+}
+
+// getStackRecoveryState returns the zero state if the annotation is missing or malformed
+func getStackRecoveryState(instance *networkingv1.Ingress, key string) stackRecoveryState {
+	parts := strings.SplitN(instance.ObjectMeta.Annotations[key], ",", 2)
+	if len(parts) != 2 {
+		return stackRecoveryState{}
+	}
+
+	attempts, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return stackRecoveryState{}
+	}
+	last, err := time.Parse(time.RFC3339, parts[1])
+	if err != nil {
+		return stackRecoveryState{}
+	}
+
+	return stackRecoveryState{attempts: attempts, last: last}
+}
+
+func (s stackRecoveryState) String() string {
+	return fmt.Sprintf("%d,%s", s.attempts, s.last.UTC().Format(time.RFC3339))
+}
+
+// backoff returns how long to wait after the last attempt before the next one
+func (s stackRecoveryState) backoff() time.Duration {
+	if s.attempts == 0 {
+		return 0
+	}
+
+	d := stackRecoveryBackoff
+	for i := 1; i < s.attempts && d < stackRecoveryMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > stackRecoveryMaxBackoff {
+		d = stackRecoveryMaxBackoff
+	}
+
+	return d
+}
+
+// recoverStack repairs a failed stack according to the recovery policy of the ingress:
+//   - ROLLBACK_COMPLETE stacks are deleted, the next reconcile creates them again
+//   - UPDATE_ROLLBACK_FAILED stacks continue the rollback, skipping the resources that failed to update
+//   - DELETE_FAILED stacks are deleted again, retaining the resources that failed to delete
+//
+// It returns a nil result when nothing was attempted, because the policy is None, the status has no strategy or
+// the retries are used up, so that callers carry on as if recovery didn't exist.
+func (r *ReconcileIngress) recoverStack(instance *networkingv1.Ingress, cfnSvc cloudformationiface.CloudFormationAPI, stackName string, stateKey string, stack *cloudformation.Stack) (*reconcile.Result, error) {
+	status := aws.StringValue(stack.StackStatus)
+	if getStackRecoveryPolicy(instance) != StackRecoveryPolicyAutomatic {
+		return nil, nil
+	}
+	if status != cloudformation.StackStatusRollbackComplete &&
+		status != cloudformation.StackStatusUpdateRollbackFailed &&
+		status != cloudformation.StackStatusDeleteFailed {
+		return nil, nil
+	}
+
+	state := getStackRecoveryState(instance, stateKey)
+	maxRetries := getStackRecoveryMaxRetries(instance)
+	if state.attempts >= maxRetries {
+		r.log.Info("stack recovery retries exhausted", zap.String("stackName", stackName), zap.Int("attempts", state.attempts))
+		r.event(instance, corev1.EventTypeWarning, EventReasonStackRecoveryExhausted, "giving up recovering stack %s from %s after %d attempts", stackName, status, state.attempts)
+		return nil, nil
+	}
+
+	if wait := time.Until(state.last.Add(state.backoff())); wait > 0 {
+		r.log.Info("backing off stack recovery", zap.String("stackName", stackName), zap.Duration("wait", wait))
+		return &reconcile.Result{RequeueAfter: wait}, nil
+	}
+
+	r.log.Info("recovering stack", zap.String("stackName", stackName), zap.String("status", status), zap.Int("attempt", state.attempts+1))
+	action, err := r.runStackRecovery(cfnSvc, stackName, status)
+
+	state = stackRecoveryState{attempts: state.attempts + 1, last: time.Now()}
+	if err := r.updateIngressAnnotation(instance, stateKey, state.String()); err != nil {
+		return nil, err
+	}
+
+	if err != nil {
+		r.log.Error("unable to recover stack", zap.String("stackName", stackName), zap.Error(err))
+		r.event(instance, corev1.EventTypeWarning, EventReasonStackOperationError, "unable to recover stack %s (attempt %d of %d): %v", stackName, state.attempts, maxRetries, err)
+		return &reconcile.Result{RequeueAfter: state.backoff()}, nil
+	}
+	r.event(instance, corev1.EventTypeNormal, EventReasonRecoveringStack, "recovering stack %s from %s: %s (attempt %d of %d)", stackName, status, action, state.attempts, maxRetries)
+
+	return &reconcile.Result{RequeueAfter: 20 * time.Second}, nil
+}
+
+// runStackRecovery starts the recovery strategy of the status and returns what it did
+func (r *ReconcileIngress) runStackRecovery(cfnSvc cloudformationiface.CloudFormationAPI, stackName string, status string) (string, error) {
+	switch status {
+	case cloudformation.StackStatusUpdateRollbackFailed:
+		skip, err := cfn.ResourcesInStatus(cfnSvc, stackName, cloudformation.ResourceStatusUpdateFailed)
+		if err != nil {
+			return "", err
+		}
+		_, err = cfnSvc.ContinueUpdateRollback(&cloudformation.ContinueUpdateRollbackInput{
+			StackName:       aws.String(stackName),
+			ResourcesToSkip: aws.StringSlice(skip),
+		})
+		return fmt.Sprintf("continuing rollback skipping %v", skip), err
+	case cloudformation.StackStatusDeleteFailed:
+		retain, err := cfn.ResourcesInStatus(cfnSvc, stackName, cloudformation.ResourceStatusDeleteFailed)
+		if err != nil {
+			return "", err
+		}
+		_, err = cfnSvc.DeleteStack(&cloudformation.DeleteStackInput{
+			StackName:       aws.String(stackName),
+			RetainResources: aws.StringSlice(retain),
+		})
+		return fmt.Sprintf("deleting retaining %v", retain), err
+	default:
+		_, err := cfnSvc.DeleteStack(&cloudformation.DeleteStackInput{
+			StackName: aws.String(stackName),
+		})
+		return "deleting to recreate", err
+	}
+}
+
+// resetStackRecovery forgets the attempts once the stack is healthy again
+func (r *ReconcileIngress) resetStackRecovery(instance *networkingv1.Ingress, stateKey string) error {
+	if _, ok := instance.ObjectMeta.Annotations[stateKey]; !ok {
+		return nil
+	}
+
+	return r.updateIngressAnnotation(instance, stateKey, "")
+}
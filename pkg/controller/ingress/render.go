@@ -0,0 +1,313 @@
+package ingress
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/apis/apigateway/v1alpha1"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/network"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+// RenderInput is what the controller looks up in the cluster and in AWS to render the stacks of an ingress
+type RenderInput struct {
+	Ingress  *networkingv1.Ingress
+	Services []corev1.Service
+	Configs  []v1alpha1.APIGatewayConfig
+	// IngressClasses and ConfigMaps hold the class of the ingress, its parameters and the controller defaults
+	IngressClasses []networkingv1.IngressClass
+	ConfigMaps     []corev1.ConfigMap
+
+	// Network stands in for the worker nodes and their VPC
+	Network *network.Network
+	// NodePort is the node port of the reverse proxy Service
+	NodePort int
+	// CertificateArns are the ACM certificates imported from the spec.tls Secrets by TLS host
+	CertificateArns map[string]string
+	// StackOutputs are the outputs of the main stack the Route53 stack points the records to
+	StackOutputs map[string]string
+}
+
+// Rendered is what the controller would deploy for an ingress
+type Rendered struct {
+	Template string
+	// Route53Template is empty without a hosted zone
+	Route53Template string
+	NginxConfig     string
+}
+
+// Render generates the stack templates and the reverse proxy config of an ingress without cluster or AWS access.
+// Like the controller it applies the IngressClass parameters, the controller defaults and the referenced
+// APIGatewayConfig first, looking them up in the input. Ingresses the controller would ignore are rejected.
+func Render(in *RenderInput) (*Rendered, error) {
+	if in.Ingress == nil {
+		return nil, fmt.Errorf("no ingress to render")
+	}
+	if in.Network == nil || in.Network.Vpc == nil {
+		return nil, fmt.Errorf("no network to render the ingress into")
+	}
+
+	instance := in.Ingress.DeepCopy()
+	if instance.Namespace == "" {
+		instance.Namespace = metav1.NamespaceDefault
+	}
+	c, err := newRenderClient(instance.Namespace, in)
+	if err != nil {
+		return nil, err
+	}
+	r := &ReconcileIngress{Client: c, log: zap.NewNop()}
+
+	class, handled, err := r.selectIngressClass(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !handled {
+		return nil, fmt.Errorf("ingress %s has no IngressClass of controller %s nor the %s class annotation", instance.Name, ControllerName, IngressClassName)
+	}
+
+	parameters, err := r.getIngressClassParameters(class)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the parameters of IngressClass %s: %v", class.Name, err)
+	}
+	applyIngressClassParameters(instance, parameters)
+
+	defaults, err := r.getControllerDefaults()
+	if err != nil {
+		return nil, err
+	}
+	applyControllerDefaults(instance, defaults)
+
+	config, err := r.getAPIGatewayConfig(instance)
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("APIGatewayConfig %s referenced by the ingress not found", instance.Annotations[IngressAnnotationConfig])
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := applyAPIGatewayConfig(instance, config); err != nil {
+		return nil, err
+	}
+
+	if errs := validateIngress(instance); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid ingress: %v", errs.ToAggregate())
+	}
+
+	// Backends without a Service are answered with 503 by the reverse proxy, as in the cluster
+	ports, _, err := backendServicePorts(instance, func(name string) (*corev1.Service, error) {
+		for i := range in.Services {
+			if in.Services[i].Name == name {
+				return &in.Services[i], nil
+			}
+		}
+		return nil, errors.NewNotFound(corev1.Resource("services"), name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A new stack is associated with the WAF right away
+	template, err := buildStackTemplate(instance, in.Network, in.NodePort, in.CertificateArns, getWAFEnabled(instance))
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := &Rendered{
+		Template:    string(template),
+		NginxConfig: buildNginxConfig(instance, ports),
+	}
+
+	if getHostedZoneName(instance) != "" {
+		mainStack := &cloudformation.Stack{}
+		for k, v := range in.StackOutputs {
+			mainStack.Outputs = append(mainStack.Outputs, &cloudformation.Output{OutputKey: aws.String(k), OutputValue: aws.String(v)})
+		}
+
+		route53Template, err := buildRoute53Template(instance, mainStack)
+		if err != nil {
+			return nil, err
+		}
+		rendered.Route53Template = string(route53Template)
+	}
+
+	return rendered, nil
+}
+
+// buildStackTemplate renders the main stack of the ingress as YAML. The nodes, subnets and security groups are
+// looked up in random order and sorted, so that the template only changes with them.
+func buildStackTemplate(instance *networkingv1.Ingress, network *network.Network, nodePort int, certificateArns map[string]string, wafAssociation bool) ([]byte, error) {
+	network = sortedNetwork(network)
+	return cfn.BuildAPIGatewayTemplateFromIngressRule(&cfn.TemplateConfig{
+		Rules:                  instance.Spec.Rules,
+		Network:                network,
+		NodePort:               nodePort,
+		Arns:                   getArns(instance),
+		StageName:              getStageName(instance),
+		CustomDomainName:       getCustomDomainName(instance),
+		CustomDomainBasePath:   getCustomDomainBasePath(instance),
+		CertificateArn:         getCertificateArn(instance),
+		TLSCertificateArns:     certificateArns,
+		APIEndpointType:        getAPIEndpointType(instance),
+		WAFEnabled:             getWAFEnabled(instance),
+		WAFRulesJSON:           getWAFRulesJSON(instance),
+		WAFScope:               getWAFScope(instance),
+		WAFAssociation:         wafAssociation,
+		RequestTimeout:         getRequestTimeout(instance),
+		TLSPolicy:              getTLSPolicy(instance),
+		UsagePlans:             getUsagePlans(instance),
+		MinimumCompressionSize: getCompressionSize(instance),
+		CachingEnabled:         getGWCacheEnabled(instance),
+		CachingSize:            getCacheSize(instance),
+		LoggingLevel:           getLoggingLevel(instance),
+		APIResources:           getAPIResources(instance),
+		AWSAPIDefinitions:      getAWSAPIConfigs(instance),
+	}).YAML()
+}
+
+// buildRoute53Template renders the Route53 stack of the ingress as YAML, pointing the records to the custom domains
+// in the outputs of the main stack
+func buildRoute53Template(instance *networkingv1.Ingress, mainStack *cloudformation.Stack) ([]byte, error) {
+	return cfn.BuildAPIGatewayRoute53Template(&cfn.Route53TemplateConfig{
+		CustomDomainName:         getCustomDomainName(instance),
+		HostedZoneName:           getHostedZoneName(instance),
+		CustomDomainHostName:     getCustomDomainCreatedHostname(mainStack),
+		CustomDomainHostedZoneID: getCustomDomainCreatedHostedZoneID(mainStack),
+		HostCustomDomains:        getHostCustomDomainsCreated(mainStack),
+	}).YAML()
+}
+
+func sortedNetwork(in *network.Network) *network.Network {
+	out := *in
+	for _, ids := range []*[]string{&out.InstanceIDs, &out.SecurityGroupIDs, &out.SubnetIDs, &out.ASGNames} {
+		*ids = append([]string{}, *ids...)
+		sort.Strings(*ids)
+	}
+
+	return &out
+}
+
+// newRenderClient serves the objects of the input to the lookups of the reconciler. Manifests without namespace
+// belong to the ingress, ConfigMaps to --ingress-class-parameters-namespace.
+func newRenderClient(namespace string, in *RenderInput) (client.Client, error) {
+	scheme, err := newManifestScheme()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []client.Object
+	for i := range in.IngressClasses {
+		objects = append(objects, in.IngressClasses[i].DeepCopy())
+	}
+	for i := range in.ConfigMaps {
+		cm := in.ConfigMaps[i].DeepCopy()
+		if cm.Namespace == "" {
+			cm.Namespace = IngressClassParametersNamespace
+		}
+		objects = append(objects, cm)
+	}
+	for i := range in.Configs {
+		config := in.Configs[i].DeepCopy()
+		if config.Namespace == "" {
+			config.Namespace = namespace
+		}
+		objects = append(objects, config)
+	}
+
+	return fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(), nil
+}
+
+func newManifestScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := v1alpha1.SchemeBuilder.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	return scheme, nil
+}
+
+// ReadManifests decodes the Ingress, networking.k8s.io/v1 or extensions/v1beta1, and the Services,
+// APIGatewayConfigs, IngressClasses and ConfigMaps of multi document YAML or JSON. Other kinds are skipped, there
+// must be exactly one Ingress.
+func ReadManifests(b []byte) (*RenderInput, error) {
+	scheme, err := newManifestScheme()
+	if err != nil {
+		return nil, err
+	}
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+
+	in := &RenderInput{}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	for i := 0; ; i++ {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Documents holding nothing but comments
+		fields := map[string]interface{}{}
+		if err := yaml.Unmarshal(doc, &fields); err != nil {
+			return nil, fmt.Errorf("document %d: %v", i, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %v", i, err)
+		}
+
+		switch obj := obj.(type) {
+		case *networkingv1.Ingress:
+			if in.Ingress != nil {
+				return nil, fmt.Errorf("document %d: more than one Ingress", i)
+			}
+			in.Ingress = obj
+		case *extensionsv1beta1.Ingress:
+			if in.Ingress != nil {
+				return nil, fmt.Errorf("document %d: more than one Ingress", i)
+			}
+			in.Ingress = convertIngress(obj)
+		case *corev1.Service:
+			in.Services = append(in.Services, *obj)
+		case *v1alpha1.APIGatewayConfig:
+			in.Configs = append(in.Configs, *obj)
+		case *networkingv1.IngressClass:
+			in.IngressClasses = append(in.IngressClasses, *obj)
+		case *corev1.ConfigMap:
+			in.ConfigMaps = append(in.ConfigMaps, *obj)
+		}
+	}
+
+	if in.Ingress == nil {
+		return nil, fmt.Errorf("no Ingress found")
+	}
+
+	return in, nil
+}
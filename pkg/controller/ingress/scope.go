@@ -0,0 +1,27 @@
+package ingress
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+var (
+	// WatchNamespaces restricts the controller to the ingresses of these namespaces, none means all namespaces
+	WatchNamespaces []string
+	// IngressSelector restricts the controller to the ingresses whose labels match
+	IngressSelector = labels.Everything()
+)
+
+// inScope tells if the ingress is served by this controller, several controllers can split the ingresses of a
+// cluster by namespace and labels. Ingresses leaving the scope are left alone, stacks included.
+func inScope(obj client.Object) bool {
+	if len(WatchNamespaces) > 0 && !contains(WatchNamespaces, obj.GetNamespace()) {
+		return false
+	}
+
+	return IngressSelector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// ingressInScope drops the events of ingresses served by other controllers
+var ingressInScope = predicate.NewPredicateFuncs(inScope)
@@ -0,0 +1,151 @@
+package ingress
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/finalizers"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+const (
+	// IngressAnnotationStackName records the name of the stack of the ingress, it is set by the controller
+	IngressAnnotationStackName = "apigateway.ingress.kubernetes.io/stack-name"
+
+	StackTagManagedBy = "managedBy"
+	StackTagClusterID = "com.github.amazon-apigateway-ingress-controller/cluster-id"
+	StackTagNamespace = "com.github.amazon-apigateway-ingress-controller/namespace"
+	StackTagIngress   = "com.github.amazon-apigateway-ingress-controller/ingress"
+
+	stackManagedBy = "amazon-apigateway-ingress-controller"
+	// legacyStackManagedBy was the managedBy tag of updated stacks before stackTags
+	legacyStackManagedBy = "aws-apigateway-ingress-controller"
+
+	// stackNameLengthLimit keeps ${AWS::StackName}-LambdaExecutionRole within the 64 characters of IAM role names
+	stackNameLengthLimit = 40
+	stackNameHashLength  = 10
+)
+
+// ClusterID tells apart the stacks of clusters sharing an AWS account and region
+var ClusterID = ""
+
+var invalidStackNameCharacters = regexp.MustCompile("[^a-zA-Z0-9-]+")
+
+// getStackName returns the stack recorded on the ingress. Ingresses the controller hasn't recorded a stack for yet
+// use the legacy name, the name of the ingress.
+func getStackName(instance *networkingv1.Ingress) string {
+	if name := instance.ObjectMeta.Annotations[IngressAnnotationStackName]; name != "" {
+		return name
+	}
+
+	return instance.ObjectMeta.Name
+}
+
+func getRoute53StackName(instance *networkingv1.Ingress) string {
+	return getStackName(instance) + Route53StackNamePostfix
+}
+
+// newStackName builds a stack name unique to the cluster, namespace and name of the ingress. The readable part is
+// truncated and a hash of all three is appended, so that long names still fit the limits of the resources named
+// after the stack.
+func newStackName(instance *networkingv1.Ingress) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s", ClusterID, instance.Namespace, instance.Name)))
+	hash := fmt.Sprintf("%x", sum)[:stackNameHashLength]
+
+	prefix := invalidStackNameCharacters.ReplaceAllString(fmt.Sprintf("%s-%s", instance.Namespace, instance.Name), "-")
+	prefix = strings.TrimLeft(prefix, "0123456789-")
+	if prefix == "" {
+		prefix = "ingress"
+	}
+	if limit := stackNameLengthLimit - stackNameHashLength - 1; len(prefix) > limit {
+		prefix = prefix[:limit]
+	}
+
+	return fmt.Sprintf("%s-%s", strings.TrimRight(prefix, "-"), hash)
+}
+
+// stackTags returns the tags of the stacks of the ingress, CloudFormation propagates them to the resources
+func stackTags(instance *networkingv1.Ingress) []*cloudformation.Tag {
+	return []*cloudformation.Tag{
+		{Key: aws.String(StackTagManagedBy), Value: aws.String(stackManagedBy)},
+		{Key: aws.String(StackTagClusterID), Value: aws.String(ClusterID)},
+		{Key: aws.String(StackTagNamespace), Value: aws.String(instance.Namespace)},
+		{Key: aws.String(StackTagIngress), Value: aws.String(instance.Name)},
+	}
+}
+
+// recordStackName picks the stack of the ingress once and records it in the stack-name annotation. Ingresses
+// from before the annotation adopt the stack named after them if it exists and can be theirs, so that upgrading
+// the controller doesn't recreate any API; all others get a new stack name.
+func (r *ReconcileIngress) recordStackName(instance *networkingv1.Ingress) error {
+	if instance.ObjectMeta.Annotations[IngressAnnotationStackName] != "" {
+		return nil
+	}
+
+	name, err := r.legacyStackName(instance)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		r.log.Info("adopting legacy stack", zap.String("name", instance.Name), zap.String("stackName", name))
+		r.event(instance, corev1.EventTypeNormal, EventReasonAdoptedStack, "adopting stack %s", name)
+	} else {
+		name = newStackName(instance)
+	}
+
+	return r.updateIngressAnnotation(instance, IngressAnnotationStackName, name)
+}
+
+// legacyStackName returns the name of the ingress if a stack of that name was created by the controller for it,
+// or "" when the ingress should get a new stack. Only ingresses the previous controller already created a stack for,
+// i.e. carrying its finalizer, adopt one. A legacy stack is left to the first ingress recording it, stacks tagged
+// with another cluster-id, namespace or ingress belong to someone else.
+func (r *ReconcileIngress) legacyStackName(instance *networkingv1.Ingress) (string, error) {
+	if !finalizers.HasFinalizer(instance, FinalizerCFNStack) {
+		return "", nil
+	}
+
+	stack, err := cfn.DescribeStack(r.cfnSvc, instance.Name)
+	if err != nil {
+		if cfn.IsDoesNotExist(err, instance.Name) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	tags := map[string]string{}
+	for _, tag := range stack.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	if managedBy := tags[StackTagManagedBy]; managedBy != stackManagedBy && managedBy != legacyStackManagedBy {
+		return "", nil
+	}
+	if clusterID, ok := tags[StackTagClusterID]; ok && clusterID != ClusterID {
+		return "", nil
+	}
+	if namespace, ok := tags[StackTagNamespace]; ok && namespace != instance.Namespace {
+		return "", nil
+	}
+	if name, ok := tags[StackTagIngress]; ok && name != instance.Name {
+		return "", nil
+	}
+
+	ingresses, err := r.listIngresses()
+	if err != nil {
+		return "", err
+	}
+	for _, ingress := range ingresses {
+		if ingress.Annotations[IngressAnnotationStackName] == instance.Name {
+			return "", nil
+		}
+	}
+
+	return instance.Name, nil
+}
@@ -0,0 +1,191 @@
+package ingress
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+)
+
+// StackTagTemplateHash records the hash of the template and tags a stack was last created or updated with, a
+// stack whose hash differs from the one of the template rendered now is updated
+const StackTagTemplateHash = "com.github.amazon-apigateway-ingress-controller/template-hash"
+
+// templateHash hashes the template with the tags of the stack, which CloudFormation propagates to the resources.
+// The template is hashed as sorted JSON without the targets of the target group, which syncNodeTargets keeps up to
+// date without updating the stack.
+func templateHash(body string, tags []*cloudformation.Tag) string {
+	h := sha256.New()
+	fmt.Fprintln(h, canonicalTemplate(body))
+	m := tagMap(tags)
+	for _, key := range sortedTagKeys(m) {
+		fmt.Fprintf(h, "%s=%s\n", key, m[key])
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func canonicalTemplate(body string) string {
+	template := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(body), &template); err != nil {
+		return body
+	}
+
+	if resources, ok := template["Resources"].(map[string]interface{}); ok {
+		if targetGroup, ok := resources[cfn.TargetGroupResourceName].(map[string]interface{}); ok {
+			if properties, ok := targetGroup["Properties"].(map[string]interface{}); ok {
+				delete(properties, "Targets")
+			}
+		}
+	}
+
+	b, err := json.Marshal(template)
+	if err != nil {
+		return body
+	}
+	return string(b)
+}
+
+// withTemplateHash returns the tags with the template-hash tag of the template added
+func withTemplateHash(body string, tags []*cloudformation.Tag) []*cloudformation.Tag {
+	return append(tags, &cloudformation.Tag{Key: aws.String(StackTagTemplateHash), Value: aws.String(templateHash(body, tags))})
+}
+
+// stackTemplateHash returns the template-hash tag of the stack, stacks created by earlier versions have none
+func stackTemplateHash(stack *cloudformation.Stack) string {
+	return tagMap(stack.Tags)[StackTagTemplateHash]
+}
+
+// stackChanged tells whether the stack was deployed with another template or tags than those rendered now. The
+// changes are logged by section and logical id, e.g. Resources.RestAPI0, comparing with the deployed template.
+func (r *ReconcileIngress) stackChanged(cfnSvc cloudformationiface.CloudFormationAPI, stack *cloudformation.Stack, body string, tags []*cloudformation.Tag) bool {
+	stackName := aws.StringValue(stack.StackName)
+	deployedHash, hash := stackTemplateHash(stack), templateHash(body, tags)
+	if deployedHash == hash {
+		r.log.Debug("stack template unchanged", zap.String("stackName", stackName))
+		return false
+	}
+
+	fields := []zap.Field{zap.String("stackName", stackName), zap.String("deployedHash", deployedHash), zap.String("hash", hash)}
+	out, err := cfnSvc.GetTemplate(&cloudformation.GetTemplateInput{
+		StackName:     stack.StackName,
+		TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+	})
+	if err != nil {
+		// The diff is only informative, the stack is updated anyway
+		r.log.Info("stack template changed, should update", append(fields, zap.NamedError("diffError", err))...)
+		return true
+	}
+
+	added, changed, removed, err := templateDiff(aws.StringValue(out.TemplateBody), body)
+	if err != nil {
+		r.log.Info("stack template changed, should update", append(fields, zap.NamedError("diffError", err))...)
+		return true
+	}
+
+	deployedTags := tagMap(stack.Tags)
+	delete(deployedTags, StackTagTemplateHash)
+	if !reflect.DeepEqual(deployedTags, tagMap(tags)) {
+		changed = append(changed, "Tags")
+	}
+
+	r.log.Info("stack template changed, should update", append(fields, zap.Strings("added", added), zap.Strings("changed", changed), zap.Strings("removed", removed))...)
+	return true
+}
+
+// templateDiff compares two YAML or JSON templates by section, and by logical id within the Resources, Outputs and
+// other sections mapping ids to their definition
+func templateDiff(from, to string) (added, changed, removed []string, err error) {
+	before := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(from), &before); err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to parse deployed template: %v", err)
+	}
+	after := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(to), &after); err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to parse template: %v", err)
+	}
+
+	for _, section := range sortedKeys(before, after) {
+		b, bok := before[section]
+		a, aok := after[section]
+		bm, bIsMap := b.(map[string]interface{})
+		am, aIsMap := a.(map[string]interface{})
+		// A section added or removed as a whole is listed by id too
+		if !bok && aIsMap {
+			bm, bIsMap = map[string]interface{}{}, true
+		}
+		if !aok && bIsMap {
+			am, aIsMap = map[string]interface{}{}, true
+		}
+		if !bIsMap || !aIsMap {
+			switch {
+			case !bok:
+				added = append(added, section)
+			case !aok:
+				removed = append(removed, section)
+			case !reflect.DeepEqual(a, b):
+				changed = append(changed, section)
+			}
+			continue
+		}
+
+		for _, id := range sortedKeys(bm, am) {
+			bv, bok := bm[id]
+			av, aok := am[id]
+			switch {
+			case !bok:
+				added = append(added, section+"."+id)
+			case !aok:
+				removed = append(removed, section+"."+id)
+			case !reflect.DeepEqual(av, bv):
+				changed = append(changed, section+"."+id)
+			}
+		}
+	}
+
+	return added, changed, removed, nil
+}
+
+func tagMap(tags []*cloudformation.Tag) map[string]string {
+	m := map[string]string{}
+	for _, tag := range tags {
+		m[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	return m
+}
+
+func sortedTagKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// sortedKeys returns the keys of both maps
+func sortedKeys(a, b map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []map[string]interface{}{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
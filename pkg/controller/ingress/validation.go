@@ -0,0 +1,57 @@
+package ingress
+
+import (
+	"fmt"
+
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// validateIngress returns the annotations and fields of the ingress the controller can't turn into a stack, on top
+// of the parse errors of the annotations.
+// Class defaults and the APIGatewayConfig are expected to be applied already.
+func validateIngress(instance *networkingv1.Ingress) field.ErrorList {
+	var errs field.ErrorList
+
+	errs = append(errs, parseAnnotations(instance)...)
+
+	for i, definition := range getAWSAPIConfigs(instance) {
+		if definition.Authorizers == nil {
+			continue
+		}
+		for j, api := range definition.APIs {
+			for k, method := range api.Methods {
+				if method.Authorization_Enabled && (method.Authorizator_Index < 0 || method.Authorizator_Index >= len(definition.Authorizers)) {
+					errs = append(errs, field.Invalid(annotationsPath.Key(IngressAnnotationAWSAPIConfigs), method.Authorizator_Index,
+						fmt.Sprintf("apis[%d].method[%d] of definition %d references authorizer %d but %d are defined", j, k, i, method.Authorizator_Index, len(definition.Authorizers))))
+				}
+			}
+		}
+	}
+
+	if domain := getCustomDomainName(instance); domain != "" && cfn.ResolveCertificateArn(getCertificateArn(instance), getTLSHosts(instance), domain) == "" {
+		errs = append(errs, field.Required(annotationsPath.Key(IngressAnnotationCertificateArn),
+			fmt.Sprintf("custom domain %s needs a certificate-arn or a spec.tls entry covering it", domain)))
+	}
+
+	return errs
+}
+
+// getTLSHosts returns the secret names of the ingress spec.tls by host, in the form ResolveCertificateArn takes
+func getTLSHosts(instance *networkingv1.Ingress) map[string]string {
+	hosts := map[string]string{}
+	for _, tls := range instance.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+		if len(tls.Hosts) == 0 {
+			hosts[""] = tls.SecretName
+		}
+		for _, host := range tls.Hosts {
+			hosts[host] = tls.SecretName
+		}
+	}
+
+	return hosts
+}
@@ -0,0 +1,246 @@
+package ingress
+
+import (
+	"context"
+
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/finalizers"
+
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// servesNetworkingV1Ingress checks whether the apiserver serves networking.k8s.io/v1 Ingresses.
+// Older clusters only serve extensions/v1beta1, in which case the controller reads those and converts them.
+func servesNetworkingV1Ingress(mapper meta.RESTMapper) bool {
+	_, err := mapper.RESTMapping(schema.GroupKind{Group: networkingv1.GroupName, Kind: "Ingress"}, networkingv1.SchemeGroupVersion.Version)
+	return err == nil
+}
+
+// newWatchedIngress returns an empty object of the Ingress version the controller watches
+func newWatchedIngress(legacyIngress bool) client.Object {
+	if legacyIngress {
+		return &extensionsv1beta1.Ingress{}
+	}
+	return &networkingv1.Ingress{}
+}
+
+func convertIngressBackend(in *extensionsv1beta1.IngressBackend) *networkingv1.IngressBackend {
+	if in == nil {
+		return nil
+	}
+
+	out := &networkingv1.IngressBackend{Resource: in.Resource}
+	if in.ServiceName != "" {
+		out.Service = &networkingv1.IngressServiceBackend{Name: in.ServiceName}
+		if in.ServicePort.Type == intstr.String {
+			out.Service.Port.Name = in.ServicePort.StrVal
+		} else {
+			out.Service.Port.Number = in.ServicePort.IntVal
+		}
+	}
+
+	return out
+}
+
+// convertIngress converts an extensions/v1beta1 Ingress to networking.k8s.io/v1 so the rest of the controller
+// only deals with a single version
+func convertIngress(in *extensionsv1beta1.Ingress) *networkingv1.Ingress {
+	out := &networkingv1.Ingress{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: in.Spec.IngressClassName,
+			DefaultBackend:   convertIngressBackend(in.Spec.Backend),
+		},
+		Status: networkingv1.IngressStatus{
+			LoadBalancer: *in.Status.LoadBalancer.DeepCopy(),
+		},
+	}
+
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, networkingv1.IngressTLS{
+			Hosts:      append([]string(nil), tls.Hosts...),
+			SecretName: tls.SecretName,
+		})
+	}
+
+	for _, rule := range in.Spec.Rules {
+		outRule := networkingv1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			outRule.HTTP = &networkingv1.HTTPIngressRuleValue{}
+			for _, path := range rule.HTTP.Paths {
+				outPath := networkingv1.HTTPIngressPath{Path: path.Path}
+				if path.PathType != nil {
+					pathType := networkingv1.PathType(*path.PathType)
+					outPath.PathType = &pathType
+				}
+				if backend := convertIngressBackend(&path.Backend); backend != nil {
+					outPath.Backend = *backend
+				}
+				outRule.HTTP.Paths = append(outRule.HTTP.Paths, outPath)
+			}
+		}
+		out.Spec.Rules = append(out.Spec.Rules, outRule)
+	}
+
+	return out
+}
+
+// getIngress fetches the Ingress in the version served by the cluster and returns it as networking.k8s.io/v1
+func (r *ReconcileIngress) getIngress(name k8stypes.NamespacedName) (*networkingv1.Ingress, error) {
+	if !r.legacyIngress {
+		instance := &networkingv1.Ingress{}
+		if err := r.Get(context.TODO(), name, instance); err != nil {
+			return nil, err
+		}
+		return instance, nil
+	}
+
+	legacy := &extensionsv1beta1.Ingress{}
+	if err := r.Get(context.TODO(), name, legacy); err != nil {
+		return nil, err
+	}
+
+	return convertIngress(legacy), nil
+}
+
+// listIngresses lists the Ingresses in scope of all namespaces in the version served by the cluster
+func (r *ReconcileIngress) listIngresses() ([]*networkingv1.Ingress, error) {
+	var ingresses []*networkingv1.Ingress
+	if !r.legacyIngress {
+		list := &networkingv1.IngressList{}
+		if err := r.List(context.TODO(), list); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			if inScope(&list.Items[i]) {
+				ingresses = append(ingresses, &list.Items[i])
+			}
+		}
+		return ingresses, nil
+	}
+
+	list := &extensionsv1beta1.IngressList{}
+	if err := r.List(context.TODO(), list); err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if inScope(&list.Items[i]) {
+			ingresses = append(ingresses, convertIngress(&list.Items[i]))
+		}
+	}
+
+	return ingresses, nil
+}
+
+// updateIngress writes the finalizers of instance back to the cluster. Annotations are left alone as the in-memory
+// object also carries the defaults of its IngressClass.
+func (r *ReconcileIngress) updateIngress(instance *networkingv1.Ingress) error {
+	name := k8stypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+	current := newWatchedIngress(r.legacyIngress)
+	if err := r.Get(context.TODO(), name, current); err != nil {
+		return err
+	}
+
+	current.SetFinalizers(instance.Finalizers)
+	current.SetResourceVersion(instance.ResourceVersion)
+	if err := r.Update(context.TODO(), current); err != nil {
+		return err
+	}
+
+	instance.ResourceVersion = current.GetResourceVersion()
+	return nil
+}
+
+// ensureFinalizer records the finalizer before the stack it guards is created or touched. A leader that loses its
+// lease between creating a stack and recording the finalizer would otherwise leave a stack behind that deleting the
+// ingress doesn't clean up.
+func (r *ReconcileIngress) ensureFinalizer(instance *networkingv1.Ingress, finalizer string) error {
+	if finalizers.HasFinalizer(instance, finalizer) {
+		return nil
+	}
+
+	instance.SetFinalizers(finalizers.AddFinalizer(instance, finalizer))
+	return r.updateIngress(instance)
+}
+
+// updateIngressAnnotation sets the annotation on the stored ingress and on instance, an empty value removes it.
+// Only controller owned annotations are written this way, the others may carry IngressClass defaults.
+func (r *ReconcileIngress) updateIngressAnnotation(instance *networkingv1.Ingress, key string, value string) error {
+	name := k8stypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+	current := newWatchedIngress(r.legacyIngress)
+	if err := r.Get(context.TODO(), name, current); err != nil {
+		return err
+	}
+
+	annotations := current.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if instance.Annotations == nil {
+		instance.Annotations = map[string]string{}
+	}
+	if value == "" {
+		delete(annotations, key)
+		delete(instance.Annotations, key)
+	} else {
+		annotations[key] = value
+		instance.Annotations[key] = value
+	}
+
+	current.SetAnnotations(annotations)
+	current.SetResourceVersion(instance.ResourceVersion)
+	if err := r.Update(context.TODO(), current); err != nil {
+		return err
+	}
+
+	instance.ResourceVersion = current.GetResourceVersion()
+	return nil
+}
+
+// updateIngressStatus writes the load balancer status of instance back to the cluster. The stored ingress is updated
+// rather than instance, which carries the class defaults and APIGatewayConfig annotations of the reconcile.
+func (r *ReconcileIngress) updateIngressStatus(instance *networkingv1.Ingress) error {
+	if !r.legacyIngress {
+		current := &networkingv1.Ingress{}
+		if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, current); err != nil {
+			return err
+		}
+
+		current.Status = *instance.Status.DeepCopy()
+		if err := r.Status().Update(context.TODO(), current); err != nil {
+			return err
+		}
+
+		instance.ResourceVersion = current.ResourceVersion
+		return nil
+	}
+
+	legacy := &extensionsv1beta1.Ingress{}
+	if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, legacy); err != nil {
+		return err
+	}
+
+	legacy.Status.LoadBalancer = *instance.Status.LoadBalancer.DeepCopy()
+	if err := r.Status().Update(context.TODO(), legacy); err != nil {
+		return err
+	}
+
+	instance.ResourceVersion = legacy.ResourceVersion
+	return nil
+}
+
+// ingressOwner returns the object used as controller reference of the reverse proxy resources. It has to carry
+// the served Ingress version, otherwise the garbage collector can't resolve the owner and removes the dependents.
+func (r *ReconcileIngress) ingressOwner(instance *networkingv1.Ingress) metav1.Object {
+	if !r.legacyIngress {
+		return instance
+	}
+
+	return &extensionsv1beta1.Ingress{ObjectMeta: *instance.ObjectMeta.DeepCopy()}
+}
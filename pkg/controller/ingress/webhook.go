@@ -0,0 +1,106 @@
+package ingress
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/logging"
+	"go.uber.org/zap"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ValidatingWebhookPath is the path the apiserver sends the Ingress admission reviews to
+const ValidatingWebhookPath = "/validate-ingress"
+
+// ingressValidator rejects ingresses of this controller whose annotations can't be turned into a stack
+type ingressValidator struct {
+	r       *ReconcileIngress
+	decoder *admission.Decoder
+}
+
+// AddValidatingWebhook registers the Ingress validating webhook with the webhook server of the Manager
+func AddValidatingWebhook(mgr manager.Manager) error {
+	r := &ReconcileIngress{Client: mgr.GetClient(), log: logging.New()}
+	mgr.GetWebhookServer().Register(ValidatingWebhookPath, &webhook.Admission{Handler: &ingressValidator{r: r}})
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (v *ingressValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+func (v *ingressValidator) decode(req admission.Request) (*networkingv1.Ingress, error) {
+	if req.Kind.Group == extensionsv1beta1.GroupName {
+		legacy := &extensionsv1beta1.Ingress{}
+		if err := v.decoder.Decode(req, legacy); err != nil {
+			return nil, err
+		}
+		return convertIngress(legacy), nil
+	}
+
+	instance := &networkingv1.Ingress{}
+	if err := v.decoder.Decode(req, instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// Handle implements admission.Handler
+func (v *ingressValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	instance, err := v.decode(req)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Removing the finalizers of a deleted ingress must always go through
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		return admission.Allowed("")
+	}
+
+	// Ingresses of other controllers are theirs to validate
+	if !inScope(instance) {
+		return admission.Allowed("")
+	}
+
+	class, handled, err := v.r.selectIngressClass(instance)
+	if err != nil {
+		v.r.log.Error("unable to select ingress class", zap.String("name", instance.Name), zap.Error(err))
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !handled {
+		return admission.Allowed("")
+	}
+
+	parameters, err := v.r.getIngressClassParameters(class)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	applyIngressClassParameters(instance, parameters)
+
+	defaults, err := v.r.getControllerDefaults()
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	applyControllerDefaults(instance, defaults)
+
+	// A missing APIGatewayConfig may still be created, the annotations are checked until then
+	config, err := v.r.getAPIGatewayConfig(instance)
+	if err != nil && !isMissing(err) {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if err := applyAPIGatewayConfig(instance, config); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if errs := validateIngress(instance); len(errs) > 0 {
+		return admission.Denied(errs.ToAggregate().Error())
+	}
+
+	return admission.Allowed("")
+}
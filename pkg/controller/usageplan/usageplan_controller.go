@@ -0,0 +1,580 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usageplan
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/apigateway"
+	"github.com/aws/aws-sdk-go/service/apigateway/apigatewayiface"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/apis/apigateway/v1alpha1"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/awssession"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/controller/ingress"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/finalizers"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/logging"
+	"go.uber.org/zap"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	FinalizerUsagePlan = "apigateway.networking.amazonaws.com/usageplan"
+	// TagUsagePlan tags the usage plans with the namespace and name of their UsagePlan
+	TagUsagePlan = "kubernetes.io/usageplan"
+)
+
+// Add creates a new UsagePlan Controller and adds it to the Manager. The controller is skipped on clusters without the
+// UsagePlan CRD.
+func Add(mgr manager.Manager) error {
+	_, err := mgr.GetRESTMapper().RESTMapping(schema.GroupKind{Group: v1alpha1.SchemeGroupVersion.Group, Kind: "UsagePlan"}, v1alpha1.SchemeGroupVersion.Version)
+	if err != nil {
+		return nil
+	}
+
+	r, err := newReconciler(mgr)
+	if err != nil {
+		return err
+	}
+
+	return add(mgr, r)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) (*ReconcileUsagePlan, error) {
+	logger := logging.New()
+	sess, err := awssession.New(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = mgr.GetRESTMapper().RESTMapping(schema.GroupKind{Group: networkingv1.GroupName, Kind: "Ingress"}, networkingv1.SchemeGroupVersion.Version)
+
+	return &ReconcileUsagePlan{
+		Client:        mgr.GetClient(),
+		scheme:        mgr.GetScheme(),
+		log:           logger,
+		cfnSvc:        cloudformation.New(sess),
+		apigatewaySvc: apigateway.New(sess),
+		legacyIngress: err != nil,
+	}, nil
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *ReconcileUsagePlan) error {
+	c, err := controller.New("usageplan-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &v1alpha1.UsagePlan{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the keys of the plans, a new key id has to be associated again
+	err = c.Watch(&source.Kind{Type: &v1alpha1.APIKey{}}, handler.EnqueueRequestsFromMapFunc(r.mapAPIKeyToUsagePlans))
+	if err != nil {
+		return err
+	}
+
+	// Watch for ingresses being deleted, their stages have to be removed from the plans before their APIs can go
+	return c.Watch(&source.Kind{Type: r.newIngress()}, handler.EnqueueRequestsFromMapFunc(r.mapIngressToUsagePlans))
+}
+
+var _ reconcile.Reconciler = &ReconcileUsagePlan{}
+
+// ReconcileUsagePlan reconciles a UsagePlan object
+type ReconcileUsagePlan struct {
+	client.Client
+	scheme        *runtime.Scheme
+	log           *zap.Logger
+	cfnSvc        cloudformationiface.CloudFormationAPI
+	apigatewaySvc apigatewayiface.APIGatewayAPI
+	legacyIngress bool
+}
+
+// Reconcile creates, updates and deletes the API Gateway usage plan of a UsagePlan, associates it with the stages
+// and keys it references and reports their ids in the status
+// +kubebuilder:rbac:groups=apigateway.networking.amazonaws.com,resources=usageplans,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=apigateway.networking.amazonaws.com,resources=usageplans/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apigateway.networking.amazonaws.com,resources=apikeys,verbs=get;list;watch
+func (r *ReconcileUsagePlan) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	instance := &v1alpha1.UsagePlan{}
+	if err := r.Get(ctx, request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !finalizers.HasFinalizer(instance, FinalizerUsagePlan) {
+			return reconcile.Result{}, nil
+		}
+
+		if err := r.delete(instance); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		instance.SetFinalizers(finalizers.RemoveFinalizer(instance, FinalizerUsagePlan))
+		return reconcile.Result{}, r.Update(ctx, instance)
+	}
+
+	if !finalizers.HasFinalizer(instance, FinalizerUsagePlan) {
+		instance.SetFinalizers(finalizers.AddFinalizer(instance, FinalizerUsagePlan))
+		if err := r.Update(ctx, instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	stages, stagesPending, err := r.resolveAPIStages(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	keyIDs, keysPending, err := r.resolveAPIKeys(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	id, err := r.apply(instance, stages)
+	if err != nil {
+		r.log.Error("unable to apply usage plan", zap.String("name", instance.Name), zap.Error(err))
+		return reconcile.Result{}, err
+	}
+
+	// Record a new plan before the keys are synced, so that retries update it rather than creating another one
+	if instance.Status.ID != id {
+		instance.Status.ID = id
+		if err := r.Status().Update(ctx, instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if err := r.syncAPIKeys(id, keyIDs); err != nil {
+		r.log.Error("unable to associate api keys with usage plan", zap.String("name", instance.Name), zap.Error(err))
+		return reconcile.Result{}, err
+	}
+
+	status := v1alpha1.UsagePlanStatus{ID: id, APIStages: stages, APIKeyIDs: keyIDs, ObservedGeneration: instance.Generation}
+	if !reflect.DeepEqual(instance.Status, status) {
+		instance.Status = status
+		if err := r.Status().Update(ctx, instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Stacks still being created and keys without an id yet are picked up later
+	if stagesPending || keysPending {
+		return reconcile.Result{RequeueAfter: 20 * time.Second}, nil
+	}
+
+	return reconcile.Result{}, nil
+}
+
+func isNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == apigateway.ErrCodeNotFoundException
+}
+
+// newIngress returns an empty object of the Ingress version served by the cluster
+func (r *ReconcileUsagePlan) newIngress() client.Object {
+	if r.legacyIngress {
+		return &extensionsv1beta1.Ingress{}
+	}
+	return &networkingv1.Ingress{}
+}
+
+// ingressStackName returns the name of the API stack of the ingress, or "" if the ingress doesn't exist or is
+// being deleted. Ingresses the ingress controller hasn't recorded a stack for yet use the legacy name, their own.
+func (r *ReconcileUsagePlan) ingressStackName(namespace string, name string) (string, error) {
+	instance := r.newIngress()
+	if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: name, Namespace: namespace}, instance); err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if !instance.GetDeletionTimestamp().IsZero() {
+		return "", nil
+	}
+
+	if stackName := instance.GetAnnotations()[ingress.IngressAnnotationStackName]; stackName != "" {
+		return stackName, nil
+	}
+	return name, nil
+}
+
+// resolveAPIStages returns the API Gateway stages of the ingresses the plan applies to. Ingresses whose stack has no
+// APIs yet are reported as pending, ingresses that don't exist or are being deleted are left out.
+func (r *ReconcileUsagePlan) resolveAPIStages(instance *v1alpha1.UsagePlan) ([]v1alpha1.APIStageStatus, bool, error) {
+	var stages []v1alpha1.APIStageStatus
+	pending := false
+	for _, s := range instance.Spec.APIStages {
+		stackName, err := r.ingressStackName(instance.Namespace, s.Ingress)
+		if err != nil {
+			return nil, false, err
+		}
+		if stackName == "" {
+			r.log.Info("ingress of usage plan stage not found", zap.String("usagePlan", instance.Name), zap.String("ingress", s.Ingress))
+			continue
+		}
+
+		stack, err := cfn.DescribeStack(r.cfnSvc, stackName)
+		if err != nil && cfn.IsDoesNotExist(err, stackName) {
+			pending = true
+			continue
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		outputs := cfn.StackOutputMap(stack)
+		if outputs[fmt.Sprintf("%s%d", cfn.OutputKeyRestAPIID, 0)] == "" {
+			pending = true
+			continue
+		}
+		for i := 0; outputs[fmt.Sprintf("%s%d", cfn.OutputKeyRestAPIID, i)] != ""; i++ {
+			stages = append(stages, v1alpha1.APIStageStatus{APIID: outputs[fmt.Sprintf("%s%d", cfn.OutputKeyRestAPIID, i)], Stage: s.Stage})
+		}
+	}
+
+	return stages, pending, nil
+}
+
+// resolveAPIKeys returns the ids of the APIKeys of the plan, keys that don't have one yet are reported as pending
+func (r *ReconcileUsagePlan) resolveAPIKeys(instance *v1alpha1.UsagePlan) ([]string, bool, error) {
+	var ids []string
+	pending := false
+	for _, ref := range instance.Spec.APIKeys {
+		key := &v1alpha1.APIKey{}
+		if err := r.Get(context.TODO(), k8stypes.NamespacedName{Name: ref.Name, Namespace: instance.Namespace}, key); err != nil {
+			if errors.IsNotFound(err) {
+				pending = true
+				continue
+			}
+			return nil, false, err
+		}
+
+		if !key.ObjectMeta.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if key.Status.ID == "" {
+			pending = true
+			continue
+		}
+		ids = append(ids, key.Status.ID)
+	}
+
+	return ids, pending, nil
+}
+
+func stageKey(stage v1alpha1.APIStageStatus) string {
+	return fmt.Sprintf("%s:%s", stage.APIID, stage.Stage)
+}
+
+// apply creates the usage plan, or updates the one in the status. Plans removed outside of the controller are created again.
+// A plan whose id never made it into the status is found by its tag and updated instead.
+func (r *ReconcileUsagePlan) apply(instance *v1alpha1.UsagePlan, stages []v1alpha1.APIStageStatus) (string, error) {
+	if instance.Status.ID != "" {
+		plan, err := r.apigatewaySvc.GetUsagePlan(&apigateway.GetUsagePlanInput{UsagePlanId: aws.String(instance.Status.ID)})
+		if err == nil {
+			return instance.Status.ID, r.update(instance, plan, stages)
+		}
+		if !isNotFound(err) {
+			return "", err
+		}
+		r.log.Info("usage plan was removed from API Gateway, creating it again", zap.String("id", instance.Status.ID))
+	}
+
+	plan, err := r.findUsagePlan(instance)
+	if err != nil {
+		return "", err
+	}
+	if plan != nil {
+		r.log.Info("found usage plan created earlier", zap.String("name", instance.Name), zap.String("id", aws.StringValue(plan.Id)))
+		return aws.StringValue(plan.Id), r.update(instance, plan, stages)
+	}
+
+	input := &apigateway.CreateUsagePlanInput{
+		Name:        aws.String(fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)),
+		Description: aws.String(instance.Spec.Description),
+		Tags: map[string]*string{
+			"managedBy":  aws.String("amazon-apigateway-ingress-controller"),
+			TagUsagePlan: aws.String(fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)),
+		},
+	}
+	for _, s := range stages {
+		input.ApiStages = append(input.ApiStages, &apigateway.ApiStage{ApiId: aws.String(s.APIID), Stage: aws.String(s.Stage)})
+	}
+	if q := instance.Spec.Quota; q != nil {
+		input.Quota = &apigateway.QuotaSettings{Limit: aws.Int64(int64(q.Limit)), Offset: aws.Int64(int64(q.Offset)), Period: aws.String(q.Period)}
+	}
+	if t := instance.Spec.Throttle; t != nil {
+		input.Throttle = &apigateway.ThrottleSettings{BurstLimit: aws.Int64(int64(t.BurstLimit)), RateLimit: aws.Float64(t.RateLimit)}
+	}
+
+	r.log.Info("creating usage plan", zap.String("name", instance.Name))
+	plan, err = r.apigatewaySvc.CreateUsagePlan(input)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(plan.Id), nil
+}
+
+// findUsagePlan returns the plan tagged with the UsagePlan, or nil if there is none
+func (r *ReconcileUsagePlan) findUsagePlan(instance *v1alpha1.UsagePlan) (*apigateway.UsagePlan, error) {
+	name := fmt.Sprintf("%s/%s", instance.Namespace, instance.Name)
+	var found *apigateway.UsagePlan
+	err := r.apigatewaySvc.GetUsagePlansPages(&apigateway.GetUsagePlansInput{}, func(out *apigateway.GetUsagePlansOutput, _ bool) bool {
+		for _, plan := range out.Items {
+			if aws.StringValue(plan.Tags[TagUsagePlan]) == name {
+				found = plan
+				return false
+			}
+		}
+		return true
+	})
+
+	return found, err
+}
+
+func replace(path string, value string) *apigateway.PatchOperation {
+	return &apigateway.PatchOperation{Op: aws.String(apigateway.OpReplace), Path: aws.String(path), Value: aws.String(value)}
+}
+
+func remove(path string) *apigateway.PatchOperation {
+	return &apigateway.PatchOperation{Op: aws.String(apigateway.OpRemove), Path: aws.String(path)}
+}
+
+// update patches the settings and stages of the plan that differ from the spec, and removes the quota and throttle
+// the spec no longer sets
+func (r *ReconcileUsagePlan) update(instance *v1alpha1.UsagePlan, plan *apigateway.UsagePlan, stages []v1alpha1.APIStageStatus) error {
+	var ops []*apigateway.PatchOperation
+	if aws.StringValue(plan.Description) != instance.Spec.Description {
+		ops = append(ops, replace("/description", instance.Spec.Description))
+	}
+
+	if q := instance.Spec.Quota; q != nil {
+		current := plan.Quota
+		if current == nil {
+			current = &apigateway.QuotaSettings{}
+		}
+		if aws.Int64Value(current.Limit) != int64(q.Limit) {
+			ops = append(ops, replace("/quota/limit", strconv.Itoa(q.Limit)))
+		}
+		if aws.Int64Value(current.Offset) != int64(q.Offset) {
+			ops = append(ops, replace("/quota/offset", strconv.Itoa(q.Offset)))
+		}
+		if aws.StringValue(current.Period) != q.Period {
+			ops = append(ops, replace("/quota/period", q.Period))
+		}
+	} else if plan.Quota != nil {
+		ops = append(ops, remove("/quota"))
+	}
+
+	if t := instance.Spec.Throttle; t != nil {
+		current := plan.Throttle
+		if current == nil {
+			current = &apigateway.ThrottleSettings{}
+		}
+		if aws.Int64Value(current.BurstLimit) != int64(t.BurstLimit) {
+			ops = append(ops, replace("/throttle/burstLimit", strconv.Itoa(t.BurstLimit)))
+		}
+		if aws.Float64Value(current.RateLimit) != t.RateLimit {
+			ops = append(ops, replace("/throttle/rateLimit", strconv.FormatFloat(t.RateLimit, 'f', -1, 64)))
+		}
+	} else if plan.Throttle != nil {
+		ops = append(ops, remove("/throttle"))
+	}
+
+	ops = append(ops, stagePatchOperations(plan.ApiStages, stages)...)
+	if len(ops) == 0 {
+		return nil
+	}
+
+	r.log.Info("updating usage plan", zap.String("name", instance.Name), zap.String("id", aws.StringValue(plan.Id)))
+	_, err := r.apigatewaySvc.UpdateUsagePlan(&apigateway.UpdateUsagePlanInput{UsagePlanId: plan.Id, PatchOperations: ops})
+	return err
+}
+
+// stagePatchOperations adds the desired stages the plan doesn't have and removes the ones it shouldn't have
+func stagePatchOperations(current []*apigateway.ApiStage, desired []v1alpha1.APIStageStatus) []*apigateway.PatchOperation {
+	have := map[string]bool{}
+	for _, s := range current {
+		have[stageKey(v1alpha1.APIStageStatus{APIID: aws.StringValue(s.ApiId), Stage: aws.StringValue(s.Stage)})] = true
+	}
+	want := map[string]bool{}
+	for _, s := range desired {
+		want[stageKey(s)] = true
+	}
+
+	var ops []*apigateway.PatchOperation
+	for _, k := range sortedKeys(want) {
+		if !have[k] {
+			ops = append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpAdd), Path: aws.String("/apiStages"), Value: aws.String(k)})
+		}
+	}
+	for _, k := range sortedKeys(have) {
+		if !want[k] {
+			ops = append(ops, &apigateway.PatchOperation{Op: aws.String(apigateway.OpRemove), Path: aws.String("/apiStages"), Value: aws.String(k)})
+		}
+	}
+
+	return ops
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// syncAPIKeys associates the keys with the plan and removes the associations of keys the plan doesn't reference anymore
+func (r *ReconcileUsagePlan) syncAPIKeys(id string, keyIDs []string) error {
+	have := map[string]bool{}
+	err := r.apigatewaySvc.GetUsagePlanKeysPages(&apigateway.GetUsagePlanKeysInput{UsagePlanId: aws.String(id)}, func(page *apigateway.GetUsagePlanKeysOutput, lastPage bool) bool {
+		for _, k := range page.Items {
+			have[aws.StringValue(k.Id)] = true
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	want := map[string]bool{}
+	for _, k := range keyIDs {
+		want[k] = true
+	}
+
+	for _, k := range sortedKeys(want) {
+		if have[k] {
+			continue
+		}
+		r.log.Info("associating api key with usage plan", zap.String("usagePlan", id), zap.String("apiKey", k))
+		_, err := r.apigatewaySvc.CreateUsagePlanKey(&apigateway.CreateUsagePlanKeyInput{UsagePlanId: aws.String(id), KeyId: aws.String(k), KeyType: aws.String("API_KEY")})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, k := range sortedKeys(have) {
+		if want[k] {
+			continue
+		}
+		r.log.Info("removing api key from usage plan", zap.String("usagePlan", id), zap.String("apiKey", k))
+		_, err := r.apigatewaySvc.DeleteUsagePlanKey(&apigateway.DeleteUsagePlanKeyInput{UsagePlanId: aws.String(id), KeyId: aws.String(k)})
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// delete removes the stages of the plan, which API Gateway requires before deleting it, and then the plan
+func (r *ReconcileUsagePlan) delete(instance *v1alpha1.UsagePlan) error {
+	if instance.Status.ID == "" {
+		return nil
+	}
+
+	plan, err := r.apigatewaySvc.GetUsagePlan(&apigateway.GetUsagePlanInput{UsagePlanId: aws.String(instance.Status.ID)})
+	if isNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if ops := stagePatchOperations(plan.ApiStages, nil); len(ops) > 0 {
+		if _, err := r.apigatewaySvc.UpdateUsagePlan(&apigateway.UpdateUsagePlanInput{UsagePlanId: plan.Id, PatchOperations: ops}); err != nil {
+			return err
+		}
+	}
+
+	r.log.Info("deleting usage plan", zap.String("name", instance.Name), zap.String("id", instance.Status.ID))
+	_, err = r.apigatewaySvc.DeleteUsagePlan(&apigateway.DeleteUsagePlanInput{UsagePlanId: plan.Id})
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// mapAPIKeyToUsagePlans enqueues the usage plans referencing an APIKey
+func (r *ReconcileUsagePlan) mapAPIKeyToUsagePlans(obj client.Object) []reconcile.Request {
+	return r.mapUsagePlans(obj.GetNamespace(), func(plan *v1alpha1.UsagePlan) bool {
+		for _, ref := range plan.Spec.APIKeys {
+			if ref.Name == obj.GetName() {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// mapIngressToUsagePlans enqueues the usage plans with stages of an ingress
+func (r *ReconcileUsagePlan) mapIngressToUsagePlans(obj client.Object) []reconcile.Request {
+	return r.mapUsagePlans(obj.GetNamespace(), func(plan *v1alpha1.UsagePlan) bool {
+		for _, s := range plan.Spec.APIStages {
+			if s.Ingress == obj.GetName() {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *ReconcileUsagePlan) mapUsagePlans(namespace string, matches func(*v1alpha1.UsagePlan) bool) []reconcile.Request {
+	plans := &v1alpha1.UsagePlanList{}
+	if err := r.List(context.TODO(), plans, client.InNamespace(namespace)); err != nil {
+		r.log.Error("unable to list usage plans", zap.Error(err))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range plans.Items {
+		if matches(&plans.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: plans.Items[i].Name, Namespace: namespace}})
+		}
+	}
+
+	return requests
+}
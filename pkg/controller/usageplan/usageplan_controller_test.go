@@ -0,0 +1,348 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usageplan
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/apigateway"
+	"github.com/aws/aws-sdk-go/service/apigateway/apigatewayiface"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/apis/apigateway/v1alpha1"
+	cfn "github.com/awslabs/amazon-apigateway-ingress-controller/pkg/cloudformation"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/controller/ingress"
+	"github.com/awslabs/amazon-apigateway-ingress-controller/pkg/logging"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type mockCloudformation struct {
+	cloudformationiface.CloudFormationAPI
+	Stacks map[string]*cloudformation.Stack
+}
+
+func (m *mockCloudformation) DescribeStacks(in *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	if s, ok := m.Stacks[*in.StackName]; ok {
+		return &cloudformation.DescribeStacksOutput{Stacks: []*cloudformation.Stack{s}}, nil
+	}
+
+	return nil, awserr.New("ValidationError", fmt.Sprintf("Stack with id %s does not exist", *in.StackName), fmt.Errorf(""))
+}
+
+type mockAPIGateway struct {
+	apigatewayiface.APIGatewayAPI
+	Plans    map[string]*apigateway.UsagePlan
+	PlanKeys map[string][]string
+}
+
+func (m *mockAPIGateway) CreateUsagePlan(in *apigateway.CreateUsagePlanInput) (*apigateway.UsagePlan, error) {
+	plan := &apigateway.UsagePlan{
+		Id:          aws.String(fmt.Sprintf("plan%d", len(m.Plans))),
+		Name:        in.Name,
+		Description: in.Description,
+		ApiStages:   in.ApiStages,
+		Quota:       in.Quota,
+		Throttle:    in.Throttle,
+		Tags:        in.Tags,
+	}
+	m.Plans[*plan.Id] = plan
+	return plan, nil
+}
+
+func (m *mockAPIGateway) GetUsagePlan(in *apigateway.GetUsagePlanInput) (*apigateway.UsagePlan, error) {
+	if plan, ok := m.Plans[*in.UsagePlanId]; ok {
+		return plan, nil
+	}
+	return nil, awserr.New(apigateway.ErrCodeNotFoundException, "Invalid Usage Plan ID specified", nil)
+}
+
+func (m *mockAPIGateway) GetUsagePlansPages(in *apigateway.GetUsagePlansInput, fn func(*apigateway.GetUsagePlansOutput, bool) bool) error {
+	out := &apigateway.GetUsagePlansOutput{}
+	for _, plan := range m.Plans {
+		out.Items = append(out.Items, plan)
+	}
+	fn(out, true)
+	return nil
+}
+
+func (m *mockAPIGateway) UpdateUsagePlan(in *apigateway.UpdateUsagePlanInput) (*apigateway.UsagePlan, error) {
+	plan := m.Plans[*in.UsagePlanId]
+	for _, op := range in.PatchOperations {
+		switch {
+		case *op.Path == "/apiStages" && *op.Op == apigateway.OpAdd:
+			parts := strings.SplitN(*op.Value, ":", 2)
+			plan.ApiStages = append(plan.ApiStages, &apigateway.ApiStage{ApiId: aws.String(parts[0]), Stage: aws.String(parts[1])})
+		case *op.Path == "/apiStages" && *op.Op == apigateway.OpRemove:
+			var stages []*apigateway.ApiStage
+			for _, s := range plan.ApiStages {
+				if *s.ApiId+":"+*s.Stage != *op.Value {
+					stages = append(stages, s)
+				}
+			}
+			plan.ApiStages = stages
+		case *op.Path == "/description":
+			plan.Description = op.Value
+		case *op.Path == "/quota/period":
+			plan.Quota.Period = op.Value
+		case *op.Path == "/quota" && *op.Op == apigateway.OpRemove:
+			plan.Quota = nil
+		case *op.Path == "/throttle" && *op.Op == apigateway.OpRemove:
+			plan.Throttle = nil
+		}
+	}
+	return plan, nil
+}
+
+func (m *mockAPIGateway) DeleteUsagePlan(in *apigateway.DeleteUsagePlanInput) (*apigateway.DeleteUsagePlanOutput, error) {
+	if len(m.Plans[*in.UsagePlanId].ApiStages) > 0 {
+		return nil, awserr.New(apigateway.ErrCodeBadRequestException, "Cannot delete Usage Plan with associated API stages", nil)
+	}
+	delete(m.Plans, *in.UsagePlanId)
+	return &apigateway.DeleteUsagePlanOutput{}, nil
+}
+
+func (m *mockAPIGateway) GetUsagePlanKeysPages(in *apigateway.GetUsagePlanKeysInput, fn func(*apigateway.GetUsagePlanKeysOutput, bool) bool) error {
+	out := &apigateway.GetUsagePlanKeysOutput{}
+	for _, k := range m.PlanKeys[*in.UsagePlanId] {
+		out.Items = append(out.Items, &apigateway.UsagePlanKey{Id: aws.String(k)})
+	}
+	fn(out, true)
+	return nil
+}
+
+func (m *mockAPIGateway) CreateUsagePlanKey(in *apigateway.CreateUsagePlanKeyInput) (*apigateway.UsagePlanKey, error) {
+	m.PlanKeys[*in.UsagePlanId] = append(m.PlanKeys[*in.UsagePlanId], *in.KeyId)
+	return &apigateway.UsagePlanKey{Id: in.KeyId}, nil
+}
+
+func (m *mockAPIGateway) DeleteUsagePlanKey(in *apigateway.DeleteUsagePlanKeyInput) (*apigateway.DeleteUsagePlanKeyOutput, error) {
+	var keys []string
+	for _, k := range m.PlanKeys[*in.UsagePlanId] {
+		if k != *in.KeyId {
+			keys = append(keys, k)
+		}
+	}
+	m.PlanKeys[*in.UsagePlanId] = keys
+	return &apigateway.DeleteUsagePlanKeyOutput{}, nil
+}
+
+func newStack(name string, apiIDs ...string) *cloudformation.Stack {
+	stack := &cloudformation.Stack{StackName: aws.String(name), StackStatus: aws.String(cloudformation.StackStatusCreateComplete)}
+	for i, id := range apiIDs {
+		stack.Outputs = append(stack.Outputs, &cloudformation.Output{OutputKey: aws.String(fmt.Sprintf("%s%d", cfn.OutputKeyRestAPIID, i)), OutputValue: aws.String(id)})
+	}
+	return stack
+}
+
+func newScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+	_ = v1alpha1.AddToScheme(s)
+	return s
+}
+
+func TestReconcileUsagePlan_Reconcile(t *testing.T) {
+	instance := &v1alpha1.UsagePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "gold", Namespace: "default"},
+		Spec: v1alpha1.UsagePlanSpec{
+			Description: "gold customers",
+			Quota:       &v1alpha1.QuotaSettings{Limit: 1000, Period: "DAY"},
+			Throttle:    &v1alpha1.ThrottleSettings{BurstLimit: 10, RateLimit: 5.5},
+			APIStages: []v1alpha1.APIStage{
+				{Ingress: "foo", Stage: "prod"},
+				{Ingress: "bar", Stage: "prod"},
+				{Ingress: "missing", Stage: "prod"},
+			},
+			APIKeys: []corev1.LocalObjectReference{{Name: "customer"}},
+		},
+	}
+	key := &v1alpha1.APIKey{
+		ObjectMeta: metav1.ObjectMeta{Name: "customer", Namespace: "default"},
+		Status:     v1alpha1.APIKeyStatus{ID: "key0"},
+	}
+	foo := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	bar := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default",
+		Annotations: map[string]string{ingress.IngressAnnotationStackName: "default-bar-0123456789"}}}
+
+	apigw := &mockAPIGateway{Plans: map[string]*apigateway.UsagePlan{}, PlanKeys: map[string][]string{}}
+	cfnSvc := &mockCloudformation{Stacks: map[string]*cloudformation.Stack{
+		"foo":                    newStack("foo", "api-foo"),
+		"default-bar-0123456789": newStack("default-bar-0123456789", "api-bar-0", "api-bar-1"),
+	}}
+	c := fakeclient.NewFakeClientWithScheme(newScheme(), instance, key, foo, bar)
+	r := &ReconcileUsagePlan{Client: c, log: logging.New(), cfnSvc: cfnSvc, apigatewaySvc: apigw}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "gold", Namespace: "default"}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileUsagePlan.Reconcile() error = %v", err)
+	}
+
+	got := &v1alpha1.UsagePlan{}
+	if err := c.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unable to fetch usage plan: %v", err)
+	}
+	wantStages := []v1alpha1.APIStageStatus{{APIID: "api-foo", Stage: "prod"}, {APIID: "api-bar-0", Stage: "prod"}, {APIID: "api-bar-1", Stage: "prod"}}
+	want := v1alpha1.UsagePlanStatus{ID: "plan0", APIStages: wantStages, APIKeyIDs: []string{"key0"}}
+	if !reflect.DeepEqual(got.Status, want) {
+		t.Errorf("status = %+v, want %+v", got.Status, want)
+	}
+	plan := apigw.Plans["plan0"]
+	if len(plan.ApiStages) != 3 || aws.Int64Value(plan.Quota.Limit) != 1000 || aws.Float64Value(plan.Throttle.RateLimit) != 5.5 {
+		t.Errorf("created plan = %v", plan)
+	}
+	if !reflect.DeepEqual(apigw.PlanKeys["plan0"], []string{"key0"}) {
+		t.Errorf("plan keys = %v, want [key0]", apigw.PlanKeys["plan0"])
+	}
+
+	// Removing a stage and a key patches the existing plan
+	got.Spec.APIStages = got.Spec.APIStages[:1]
+	got.Spec.APIKeys = nil
+	got.Spec.Quota.Period = "WEEK"
+	if err := c.Update(context.TODO(), got); err != nil {
+		t.Fatalf("unable to update usage plan: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileUsagePlan.Reconcile() error = %v", err)
+	}
+	if len(plan.ApiStages) != 1 || aws.StringValue(plan.ApiStages[0].ApiId) != "api-foo" || aws.StringValue(plan.Quota.Period) != "WEEK" {
+		t.Errorf("updated plan = %v", plan)
+	}
+	if len(apigw.PlanKeys["plan0"]) != 0 {
+		t.Errorf("plan keys = %v, want none", apigw.PlanKeys["plan0"])
+	}
+
+	// Dropping the quota and throttle removes them from the plan
+	if err := c.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unable to fetch usage plan: %v", err)
+	}
+	got.Spec.Quota = nil
+	got.Spec.Throttle = nil
+	if err := c.Update(context.TODO(), got); err != nil {
+		t.Fatalf("unable to update usage plan: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileUsagePlan.Reconcile() error = %v", err)
+	}
+	if plan.Quota != nil || plan.Throttle != nil {
+		t.Errorf("updated plan = %v, want no quota and throttle", plan)
+	}
+
+	if requests := r.mapIngressToUsagePlans(foo); len(requests) != 1 || requests[0].Name != "gold" {
+		t.Errorf("ReconcileUsagePlan.mapIngressToUsagePlans() = %v, want gold", requests)
+	}
+	if requests := r.mapAPIKeyToUsagePlans(key); len(requests) != 0 {
+		t.Errorf("ReconcileUsagePlan.mapAPIKeyToUsagePlans() = %v, want none", requests)
+	}
+}
+
+func TestReconcileUsagePlan_adopt(t *testing.T) {
+	instance := &v1alpha1.UsagePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "gold", Namespace: "default"},
+		Spec:       v1alpha1.UsagePlanSpec{Description: "gold customers"},
+	}
+	// The plan of a pass that failed to record it in the status
+	apigw := &mockAPIGateway{
+		Plans: map[string]*apigateway.UsagePlan{
+			"earlier": {Id: aws.String("earlier"), Tags: aws.StringMap(map[string]string{TagUsagePlan: "default/gold"})},
+		},
+		PlanKeys: map[string][]string{},
+	}
+	c := fakeclient.NewFakeClientWithScheme(newScheme(), instance)
+	r := &ReconcileUsagePlan{Client: c, log: logging.New(), cfnSvc: &mockCloudformation{}, apigatewaySvc: apigw}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "gold", Namespace: "default"}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileUsagePlan.Reconcile() error = %v", err)
+	}
+
+	got := &v1alpha1.UsagePlan{}
+	if err := c.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unable to fetch usage plan: %v", err)
+	}
+	if got.Status.ID != "earlier" || len(apigw.Plans) != 1 {
+		t.Errorf("status.id = %q with %d plans, want the earlier plan", got.Status.ID, len(apigw.Plans))
+	}
+	if aws.StringValue(apigw.Plans["earlier"].Description) != "gold customers" {
+		t.Errorf("earlier plan = %v, want it updated", apigw.Plans["earlier"])
+	}
+}
+
+func TestReconcileUsagePlan_pending(t *testing.T) {
+	instance := &v1alpha1.UsagePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "gold", Namespace: "default"},
+		Spec: v1alpha1.UsagePlanSpec{
+			APIStages: []v1alpha1.APIStage{{Ingress: "foo", Stage: "prod"}},
+			APIKeys:   []corev1.LocalObjectReference{{Name: "customer"}},
+		},
+	}
+	foo := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	apigw := &mockAPIGateway{Plans: map[string]*apigateway.UsagePlan{}, PlanKeys: map[string][]string{}}
+	c := fakeclient.NewFakeClientWithScheme(newScheme(), instance, foo)
+	r := &ReconcileUsagePlan{Client: c, log: logging.New(), cfnSvc: &mockCloudformation{}, apigatewaySvc: apigw}
+
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "gold", Namespace: "default"}})
+	if err != nil {
+		t.Fatalf("ReconcileUsagePlan.Reconcile() error = %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Errorf("ReconcileUsagePlan.Reconcile() = %v, want a requeue while the stack and key are pending", result)
+	}
+}
+
+func TestReconcileUsagePlan_delete(t *testing.T) {
+	now := metav1.Now()
+	instance := &v1alpha1.UsagePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "gold", Namespace: "default", DeletionTimestamp: &now, Finalizers: []string{FinalizerUsagePlan}},
+		Status:     v1alpha1.UsagePlanStatus{ID: "plan0"},
+	}
+	apigw := &mockAPIGateway{
+		Plans: map[string]*apigateway.UsagePlan{
+			"plan0": {Id: aws.String("plan0"), ApiStages: []*apigateway.ApiStage{{ApiId: aws.String("api-foo"), Stage: aws.String("prod")}}},
+		},
+		PlanKeys: map[string][]string{},
+	}
+	c := fakeclient.NewFakeClientWithScheme(newScheme(), instance)
+	r := &ReconcileUsagePlan{Client: c, log: logging.New(), cfnSvc: &mockCloudformation{}, apigatewaySvc: apigw}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "gold", Namespace: "default"}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("ReconcileUsagePlan.Reconcile() error = %v", err)
+	}
+	if len(apigw.Plans) != 0 {
+		t.Errorf("plans = %v, want the plan deleted", apigw.Plans)
+	}
+
+	got := &v1alpha1.UsagePlan{}
+	if err := c.Get(context.TODO(), request.NamespacedName, got); err != nil {
+		t.Fatalf("unable to fetch usage plan: %v", err)
+	}
+	if len(got.Finalizers) != 0 {
+		t.Errorf("finalizers = %v, want none", got.Finalizers)
+	}
+}
@@ -0,0 +1,147 @@
+// Package namespacedcache restricts the cache of the manager to a set of namespaces
+package namespacedcache
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// New returns a cache builder watching namespaced objects in the namespaces only, all namespaces if there are none.
+// Cluster scoped objects, like Nodes and IngressClasses, are watched cluster wide: the multi-namespace cache of
+// controller-runtime can't get them and lists them once per namespace.
+func New(namespaces []string) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		switch len(namespaces) {
+		case 0:
+			return cache.New(config, opts)
+		case 1:
+			opts.Namespace = namespaces[0]
+			return cache.New(config, opts)
+		}
+
+		if opts.Scheme == nil {
+			opts.Scheme = scheme.Scheme
+		}
+		if opts.Mapper == nil {
+			mapper, err := apiutil.NewDynamicRESTMapper(config)
+			if err != nil {
+				return nil, err
+			}
+			opts.Mapper = mapper
+		}
+
+		namespaced, err := cache.MultiNamespacedCacheBuilder(namespaces)(config, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts.Namespace = ""
+		cluster, err := cache.New(config, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return &scopedCache{namespaced: namespaced, cluster: cluster, scheme: opts.Scheme, mapper: opts.Mapper}, nil
+	}
+}
+
+// scopedCache sends cluster scoped objects to the cluster wide cache and all others to the namespaced one
+type scopedCache struct {
+	namespaced cache.Cache
+	cluster    cache.Cache
+	scheme     *runtime.Scheme
+	mapper     meta.RESTMapper
+}
+
+var _ cache.Cache = &scopedCache{}
+
+func (c *scopedCache) cacheForKind(gvk schema.GroupVersionKind) (cache.Cache, error) {
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return c.cluster, nil
+	}
+
+	return c.namespaced, nil
+}
+
+func (c *scopedCache) cacheFor(obj runtime.Object) (cache.Cache, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return nil, err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	return c.cacheForKind(gvk)
+}
+
+func (c *scopedCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	cache, err := c.cacheFor(obj)
+	if err != nil {
+		return err
+	}
+
+	return cache.Get(ctx, key, obj)
+}
+
+func (c *scopedCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	cache, err := c.cacheFor(list)
+	if err != nil {
+		return err
+	}
+
+	return cache.List(ctx, list, opts...)
+}
+
+func (c *scopedCache) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
+	cache, err := c.cacheFor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.GetInformer(ctx, obj)
+}
+
+func (c *scopedCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	cache, err := c.cacheForKind(gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.GetInformerForKind(ctx, gvk)
+}
+
+func (c *scopedCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	cache, err := c.cacheFor(obj)
+	if err != nil {
+		return err
+	}
+
+	return cache.IndexField(ctx, obj, field, extractValue)
+}
+
+// Start runs both caches until the context is closed
+func (c *scopedCache) Start(ctx context.Context) error {
+	errs := make(chan error, 2)
+	go func() { errs <- c.cluster.Start(ctx) }()
+	go func() { errs <- c.namespaced.Start(ctx) }()
+
+	if err := <-errs; err != nil {
+		return err
+	}
+	return <-errs
+}
+
+func (c *scopedCache) WaitForCacheSync(ctx context.Context) bool {
+	return c.cluster.WaitForCacheSync(ctx) && c.namespaced.WaitForCacheSync(ctx)
+}
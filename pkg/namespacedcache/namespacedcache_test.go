@@ -0,0 +1,100 @@
+package namespacedcache
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// recordingCache remembers the calls it got, all other methods are left unimplemented
+type recordingCache struct {
+	cache.Cache
+	calls int
+}
+
+func (c *recordingCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	c.calls++
+	return nil
+}
+
+func (c *recordingCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.calls++
+	return nil
+}
+
+func (c *recordingCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	c.calls++
+	return nil, nil
+}
+
+func TestScopedCache(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Node"), meta.RESTScopeRoot)
+	mapper.Add(networkingv1.SchemeGroupVersion.WithKind("IngressClass"), meta.RESTScopeRoot)
+	mapper.Add(networkingv1.SchemeGroupVersion.WithKind("Ingress"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+
+	tests := []struct {
+		name        string
+		call        func(c cache.Cache) error
+		wantCluster bool
+	}{
+		{
+			name:        "get node",
+			call:        func(c cache.Cache) error { return c.Get(context.TODO(), client.ObjectKey{Name: "foo"}, &corev1.Node{}) },
+			wantCluster: true,
+		},
+		{
+			name:        "list nodes",
+			call:        func(c cache.Cache) error { return c.List(context.TODO(), &corev1.NodeList{}) },
+			wantCluster: true,
+		},
+		{
+			name: "get ingress class",
+			call: func(c cache.Cache) error {
+				return c.Get(context.TODO(), client.ObjectKey{Name: "foo"}, &networkingv1.IngressClass{})
+			},
+			wantCluster: true,
+		},
+		{
+			name: "get ingress",
+			call: func(c cache.Cache) error {
+				return c.Get(context.TODO(), client.ObjectKey{Name: "foo", Namespace: "team-a"}, &networkingv1.Ingress{})
+			},
+		},
+		{
+			name: "list services",
+			call: func(c cache.Cache) error { return c.List(context.TODO(), &corev1.ServiceList{}) },
+		},
+		{
+			name: "service informer",
+			call: func(c cache.Cache) error {
+				_, err := c.GetInformerForKind(context.TODO(), corev1.SchemeGroupVersion.WithKind("Service"))
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespaced, cluster := &recordingCache{}, &recordingCache{}
+			c := &scopedCache{namespaced: namespaced, cluster: cluster, scheme: scheme.Scheme, mapper: mapper}
+
+			if err := tt.call(c); err != nil {
+				t.Fatalf("call error = %v", err)
+			}
+			if tt.wantCluster && (cluster.calls != 1 || namespaced.calls != 0) {
+				t.Errorf("cluster calls = %d, namespaced calls = %d, want the cluster cache only", cluster.calls, namespaced.calls)
+			}
+			if !tt.wantCluster && (namespaced.calls != 1 || cluster.calls != 0) {
+				t.Errorf("cluster calls = %d, namespaced calls = %d, want the namespaced cache only", cluster.calls, namespaced.calls)
+			}
+		})
+	}
+}